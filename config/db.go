@@ -13,11 +13,19 @@ import (
 
 var DB *gorm.DB
 
+// Models lista los modelos que se migran; la eliminación en cascada los recorre
+// para encontrar las tablas de unión que apuntan a una entidad.
+var Models = []interface{}{
+	&models.User{}, &models.IIO{}, &models.Persona{}, &models.Vehiculo{},
+	&models.Empresa{}, &models.Direccion{}, &models.Pasaporte{}, &models.Visa{}, &models.Tie{}, &models.Modalidad{},
+	&models.Documento{}, &models.Caso{}, &models.Correo{}, &models.Redes{}, &models.TemporaryAccess{}, &models.Nacionalidad{}, &models.Mensaje{}, &models.AuditLog{},
+}
+
 func init() {
-	// Cargar el archivo .env
+	// Cargar el archivo .env; si no existe se usan las variables de entorno del proceso
 	err := godotenv.Load() // Carga las variables de entorno desde el archivo .env
 	if err != nil {
-		log.Fatal("Error loading .env file")
+		log.Println("No .env file found, using process environment")
 	}
 }
 
@@ -39,11 +47,7 @@ func ConnectToDB() {
 	DB = db
 
 	// Migrar las tablas
-	err = db.AutoMigrate(
-		&models.User{}, &models.IIO{}, &models.Persona{}, &models.Vehiculo{},
-		&models.Empresa{}, &models.Direccion{}, &models.Pasaporte{}, &models.Visa{}, &models.Tie{}, &models.Modalidad{},
-		&models.Documento{}, &models.Caso{}, &models.Correo{}, &models.Redes{}, &models.TemporaryAccess{}, &models.Nacionalidad{}, &models.Mensaje{},
-	)
+	err = db.AutoMigrate(Models...)
 	if err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	configs "github.com/oficialrivas/sgi/config"
+	"github.com/oficialrivas/sgi/models"
+	"gorm.io/gorm"
+)
+
+// detalleIDs es el Detalle de una entrada del audit log que cubre varias entidades
+type detalleIDs struct {
+	IDs []uuid.UUID `json:"ids"`
+}
+
+// registrarAuditoria guarda en el audit log, con db, una acción del usuario
+// autenticado sobre las entidades indicadas. Una sola entidad va en EntityID;
+// varias se guardan en una única entrada con EntityID vacío y los IDs en Detalle.
+func registrarAuditoria(db *gorm.DB, c *gin.Context, accion, entityType string, entityIDs ...uuid.UUID) error {
+	if len(entityIDs) == 0 {
+		return nil
+	}
+
+	entry := models.AuditLog{
+		UserID:     usuarioAutenticado(c),
+		Accion:     accion,
+		EntityType: entityType,
+		EntityID:   entityIDs[0],
+	}
+	if len(entityIDs) > 1 {
+		detalle, err := json.Marshal(detalleIDs{IDs: entityIDs})
+		if err != nil {
+			return err
+		}
+		entry.EntityID = uuid.Nil
+		entry.Detalle = string(detalle)
+	}
+
+	return db.Create(&entry).Error
+}
+
+// registrarConsulta registra que la respuesta incluye los datos de las personas
+// indicadas. Si no se puede registrar responde con error y devuelve false, para
+// que el handler no entregue datos sin dejar constancia.
+func registrarConsulta(c *gin.Context, ids ...uuid.UUID) bool {
+	if err := registrarAuditoria(configs.DB, c, models.AccionConsultar, "personas", ids...); err != nil {
+		log.Printf("Failed to write audit log (%s personas): %v", models.AccionConsultar, err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Error al registrar la auditoría"})
+		return false
+	}
+	return true
+}
+
+// usuarioAutenticado devuelve el ID del usuario que AuthRequired dejó en el contexto
+func usuarioAutenticado(c *gin.Context) uuid.UUID {
+	var userID uuid.UUID
+	if id, exists := c.Get("userID"); exists {
+		userID, _ = uuid.Parse(id.(string))
+	}
+	return userID
+}
+
+// personaIDs devuelve los IDs de las personas para registrarlas en el audit log
+func personaIDs(grupos ...[]models.Persona) []uuid.UUID {
+	var ids []uuid.UUID
+	for _, personas := range grupos {
+		for _, persona := range personas {
+			ids = append(ids, persona.ID)
+		}
+	}
+	return ids
+}
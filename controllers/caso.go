@@ -92,6 +92,10 @@ func GetCasoByID(c *gin.Context) {
 		return
 	}
 
+	if !registrarConsulta(c, personaIDs(caso.Relacion)...) {
+		return
+	}
+
 	c.JSON(http.StatusOK, caso)
 }
 
@@ -159,7 +163,7 @@ func DeleteCaso(c *gin.Context) {
 		return
 	}
 
-	if err := configs.DB.Delete(&caso).Error; err != nil {
+	if err := eliminarEntidad(c, &models.Caso{}, "casos", caso.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar el caso"})
 		return
 	}
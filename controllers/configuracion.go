@@ -9,6 +9,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/oficialrivas/sgi/config"
 	"github.com/oficialrivas/sgi/models"
+	"gorm.io/gorm"
 )
 
 type AccessRequest struct {
@@ -46,7 +47,15 @@ func GrantTemporaryAccess(c *gin.Context) {
 		ExpiresAt:  request.ExpiresAt,
 	}
 
-	if err := configs.DB.Create(&access).Error; err != nil {
+	// El otorgamiento queda en el audit log, que es de donde lo cuenta el reporte
+	// de transparencia aunque la fila cambie o se borre después
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&access).Error; err != nil {
+			return err
+		}
+		return registrarAuditoria(tx, c, models.AccionCrear, access.TableName(), access.ID)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
 		return
 	}
@@ -161,7 +161,7 @@ func DeleteCorreo(c *gin.Context) {
 		return
 	}
 
-	if err := configs.DB.Delete(&correo).Error; err != nil {
+	if err := eliminarEntidad(c, &models.Correo{}, "correos", correo.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar el correo"})
 		return
 	}
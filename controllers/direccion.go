@@ -82,6 +82,10 @@ func GetDireccionByID(c *gin.Context) {
 		return
 	}
 
+	if !registrarConsulta(c, personaIDs(direccion.Usuarios, direccion.Empleados)...) {
+		return
+	}
+
 	c.JSON(http.StatusOK, direccion)
 }
 
@@ -139,7 +143,10 @@ func DeleteDireccion(c *gin.Context) {
 		return
 	}
 
-	configs.DB.Delete(&direccion)
+	if err := eliminarEntidad(c, &models.Direccion{}, "direcciones", direccion.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar la dirección"})
+		return
+	}
 
 	c.Status(http.StatusNoContent)
 }
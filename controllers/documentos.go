@@ -198,13 +198,7 @@ func DeleteDocumento(c *gin.Context) {
 		return
 	}
 
-	// Eliminar el archivo asociado si existe
-	if documento.Documento != "" {
-		filepath := filepath.Join("static/documentos", documento.Documento)
-		os.Remove(filepath)
-	}
-
-	if err := configs.DB.Delete(&documento).Error; err != nil {
+	if err := eliminarEntidad(c, &models.Documento{}, "documentos", documento.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar el documento"})
 		return
 	}
@@ -0,0 +1,373 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	configs "github.com/oficialrivas/sgi/config"
+	"github.com/oficialrivas/sgi/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// dependientes lista, por tabla, los registros que pertenecen a una entidad y
+// se eliminan con ella. Las demás referencias directas (belongs-to) sólo se
+// desvinculan dejando la columna en NULL.
+var dependientes = map[string][]interface{}{
+	"persona": {&models.Pasaporte{}, &models.Visa{}, &models.Correo{}, &models.Redes{}},
+}
+
+// referenciasSinRelacion lista, por tabla, la columna con la que otras tablas la
+// referencian sin una relación declarada en gorm (user_id guarda quién creó cada
+// registro). El audit log conserva esas referencias a propósito y los accesos
+// temporales se tratan aparte.
+var referenciasSinRelacion = map[string]string{
+	models.User{}.TableName(): "user_id",
+}
+
+var schemaCache = &sync.Map{}
+
+// certificadoEliminacion detalla lo que se borró junto con una entidad; se guarda
+// como JSON en el campo Detalle de la entrada del audit log. Los archivos sólo se
+// borran después de confirmar la transacción, así que aquí figuran como
+// pendientes y el resultado se registra en una entrada aparte. Los que otro
+// registro todavía usa se conservan y se listan como compartidos.
+type certificadoEliminacion struct {
+	Entidad             string           `json:"entidad"`
+	ID                  uuid.UUID        `json:"id"`
+	Eliminadas          map[string]int64 `json:"filas_eliminadas"`
+	Desvinculadas       map[string]int64 `json:"filas_desvinculadas"`
+	ArchivosPorBorrar   []string         `json:"archivos_por_borrar"`
+	ArchivosCompartidos []string         `json:"archivos_conservados_compartidos"`
+}
+
+// resultadoBorrado es el Detalle de la entrada del audit log que registra qué
+// archivos de una entidad eliminada se borraron y cuáles no.
+type resultadoBorrado struct {
+	Borrados []string          `json:"archivos_borrados"`
+	Fallidos map[string]string `json:"archivos_fallidos"`
+}
+
+// eliminarEntidad borra la entidad con todas sus asociaciones en una transacción
+// y registra el certificado de eliminación en el audit log. Los archivos asociados
+// se borran sólo después de confirmar la transacción.
+func eliminarEntidad(c *gin.Context, value interface{}, entityType string, id uuid.UUID) error {
+	cert := certificadoEliminacion{
+		Entidad:             entityType,
+		ID:                  id,
+		Eliminadas:          map[string]int64{},
+		Desvinculadas:       map[string]int64{},
+		ArchivosPorBorrar:   []string{},
+		ArchivosCompartidos: []string{},
+	}
+
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := eliminarEnCascada(tx, value, id, &cert); err != nil {
+			return err
+		}
+
+		detalle, err := json.Marshal(cert)
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(&models.AuditLog{
+			UserID:     usuarioAutenticado(c),
+			Accion:     models.AccionEliminar,
+			EntityType: entityType,
+			EntityID:   id,
+			Detalle:    string(detalle),
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(cert.ArchivosPorBorrar) > 0 {
+		borrarArchivos(c, entityType, id, cert.ArchivosPorBorrar)
+	}
+
+	return nil
+}
+
+// borrarArchivos borra los archivos de una entidad ya eliminada y registra en el
+// audit log el resultado de cada uno, para que el certificado no dé por borrado
+// un archivo que sigue en disco.
+func borrarArchivos(c *gin.Context, entityType string, id uuid.UUID, archivos []string) {
+	resultado := resultadoBorrado{Borrados: []string{}, Fallidos: map[string]string{}}
+	for _, archivo := range archivos {
+		if err := borrarArchivo(archivo); err != nil {
+			log.Printf("Failed to scrub %s after deleting %s %s: %v", archivo, entityType, id, err)
+			resultado.Fallidos[archivo] = err.Error()
+			continue
+		}
+		resultado.Borrados = append(resultado.Borrados, archivo)
+	}
+
+	detalle, err := json.Marshal(resultado)
+	if err == nil {
+		err = configs.DB.Create(&models.AuditLog{
+			UserID:     usuarioAutenticado(c),
+			Accion:     models.AccionBorrarArchivos,
+			EntityType: entityType,
+			EntityID:   id,
+			Detalle:    string(detalle),
+		}).Error
+	}
+	if err != nil {
+		log.Printf("Failed to record scrub result for %s %s: %v", entityType, id, err)
+	}
+}
+
+// eliminarEnCascada borra la fila de la entidad, sus dependientes, sus filas en
+// las tablas de unión y los accesos temporales, y desvincula las referencias
+// directas desde otras tablas.
+func eliminarEnCascada(tx *gorm.DB, value interface{}, id uuid.UUID, cert *certificadoEliminacion) error {
+	if err := tx.First(value, "id = ?", id).Error; err != nil {
+		return err
+	}
+
+	target, err := schema.Parse(value, schemaCache, tx.NamingStrategy)
+	if err != nil {
+		return err
+	}
+
+	for _, dependiente := range dependientes[target.Table] {
+		if err := eliminarDependientes(tx, dependiente, target.Table, id, cert); err != nil {
+			return err
+		}
+	}
+
+	for _, model := range configs.Models {
+		owner, err := schema.Parse(model, schemaCache, tx.NamingStrategy)
+		if err != nil {
+			return err
+		}
+
+		for _, rel := range owner.Relationships.Many2Many {
+			for _, ref := range rel.References {
+				side := rel.FieldSchema
+				if ref.OwnPrimaryKey {
+					side = rel.Schema
+				}
+				if side.Table != target.Table {
+					continue
+				}
+				if err := borrarFilas(tx, rel.JoinTable.Table, ref.ForeignKey.DBName, id, cert); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, rel := range owner.Relationships.BelongsTo {
+			if rel.FieldSchema.Table != target.Table {
+				continue
+			}
+			for _, ref := range rel.References {
+				if err := desvincularFilas(tx, owner.Table, ref.ForeignKey.DBName, id, cert); err != nil {
+					return err
+				}
+			}
+		}
+
+		if column, ok := referenciasSinRelacion[target.Table]; ok && owner.LookUpField(column) != nil {
+			if owner.Table == (models.AuditLog{}).TableName() || owner.Table == (models.TemporaryAccess{}).TableName() {
+				continue
+			}
+			if err := desvincularFilas(tx, owner.Table, column, id, cert); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := anonimizarAccesos(tx, id, cert); err != nil {
+		return err
+	}
+
+	result := tx.Delete(value)
+	if result.Error != nil {
+		return result.Error
+	}
+	cert.Eliminadas[target.Table] += result.RowsAffected
+
+	// Con la fila ya borrada, cualquier referencia que quede al archivo es de otro registro
+	for _, archivo := range archivosAsociados(value) {
+		compartido, err := archivoEnUso(tx, archivo)
+		if err != nil {
+			return err
+		}
+		if compartido {
+			cert.ArchivosCompartidos = append(cert.ArchivosCompartidos, archivo)
+			continue
+		}
+		cert.ArchivosPorBorrar = append(cert.ArchivosPorBorrar, archivo)
+	}
+
+	return nil
+}
+
+// eliminarDependientes borra en cascada los registros de model que apuntan a la
+// entidad de la tabla parentTable.
+func eliminarDependientes(tx *gorm.DB, model interface{}, parentTable string, id uuid.UUID, cert *certificadoEliminacion) error {
+	dependiente, err := schema.Parse(model, schemaCache, tx.NamingStrategy)
+	if err != nil {
+		return err
+	}
+
+	for _, rel := range dependiente.Relationships.BelongsTo {
+		if rel.FieldSchema.Table != parentTable {
+			continue
+		}
+		for _, ref := range rel.References {
+			var ids []uuid.UUID
+			if err := tx.Model(model).Where(clause.Eq{Column: clause.Column{Name: ref.ForeignKey.DBName}, Value: id}).Pluck("id", &ids).Error; err != nil {
+				return err
+			}
+			for _, depID := range ids {
+				value := reflect.New(reflect.TypeOf(model).Elem()).Interface()
+				if err := eliminarEnCascada(tx, value, depID, cert); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// borrarFilas elimina las filas de una tabla de unión que apuntan a la entidad.
+// Las tablas de unión compartidas sólo tienen las columnas del primer modelo que
+// las creó, por eso se comprueba que la columna exista.
+func borrarFilas(tx *gorm.DB, table, column string, id uuid.UUID, cert *certificadoEliminacion) error {
+	if !tx.Migrator().HasColumn(table, column) {
+		return nil
+	}
+
+	result := tx.Exec("DELETE FROM ? WHERE ? = ?", clause.Table{Name: table}, clause.Column{Name: column}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		cert.Eliminadas[table] += result.RowsAffected
+	}
+	return nil
+}
+
+// desvincularFilas deja en NULL la columna que referencia a la entidad.
+func desvincularFilas(tx *gorm.DB, table, column string, id uuid.UUID, cert *certificadoEliminacion) error {
+	result := tx.Exec("UPDATE ? SET ? = NULL WHERE ? = ?", clause.Table{Name: table}, clause.Column{Name: column}, clause.Column{Name: column}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		cert.Desvinculadas[table+"."+column] += result.RowsAffected
+	}
+	return nil
+}
+
+// anonimizarAccesos vence los accesos temporales sobre la entidad o del usuario
+// eliminado y quita la referencia. Las filas no se borran: los otorgamientos ya
+// hechos siguen constando.
+func anonimizarAccesos(tx *gorm.DB, id uuid.UUID, cert *certificadoEliminacion) error {
+	ahora := time.Now().UTC()
+	for _, column := range []string{"entity_id", "user_id"} {
+		result := tx.Model(&models.TemporaryAccess{}).
+			Where(clause.Eq{Column: clause.Column{Name: column}, Value: id}).
+			Updates(map[string]interface{}{
+				column:       uuid.Nil,
+				"expires_at": gorm.Expr("LEAST(expires_at, ?)", ahora),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			cert.Desvinculadas[models.TemporaryAccess{}.TableName()+"."+column] += result.RowsAffected
+		}
+	}
+	return nil
+}
+
+// archivosAsociados devuelve las rutas de los archivos subidos para la entidad
+func archivosAsociados(value interface{}) []string {
+	var archivos []string
+	switch entity := value.(type) {
+	case *models.Pasaporte:
+		if entity.Foto != "" {
+			archivos = append(archivos, filepath.Join("static", entity.Foto))
+		}
+	case *models.Documento:
+		if entity.Documento != "" {
+			archivos = append(archivos, filepath.Join("static/documentos", entity.Documento))
+		}
+	case *models.IIO:
+		if entity.ImagenURL != "" {
+			archivos = append(archivos, filepath.FromSlash(entity.ImagenURL))
+		}
+	case *models.Mensaje:
+		if entity.ImagenURL != "" {
+			archivos = append(archivos, filepath.FromSlash(entity.ImagenURL))
+		}
+	}
+	return archivos
+}
+
+// archivoEnUso indica si alguna IIO o Mensaje todavía apunta al archivo. Sus
+// imágenes se guardan con el nombre que envía el cliente, así que varias filas
+// pueden compartir el mismo archivo.
+func archivoEnUso(tx *gorm.DB, archivo string) (bool, error) {
+	for _, model := range []interface{}{&models.IIO{}, &models.Mensaje{}} {
+		var n int64
+		if err := tx.Model(model).Where("imagen_url = ?", filepath.ToSlash(archivo)).Count(&n).Error; err != nil {
+			return false, err
+		}
+		if n > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// borrarArchivo sobrescribe con ceros y elimina un archivo dentro de static.
+// Un archivo que ya no existe cuenta como borrado.
+func borrarArchivo(path string) error {
+	path = filepath.Clean(path)
+	if !strings.HasPrefix(path, "static"+string(filepath.Separator)) {
+		return fmt.Errorf("refusing to scrub %s: outside the static directory", path)
+	}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(make([]byte, info.Size())); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
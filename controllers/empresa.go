@@ -81,6 +81,10 @@ func GetEmpresaByID(c *gin.Context) {
 		return
 	}
 
+	if !registrarConsulta(c, personaIDs(empresa.Socios, empresa.Empleados)...) {
+		return
+	}
+
 	c.JSON(http.StatusOK, empresa)
 }
 
@@ -105,6 +109,10 @@ func GetEmpresaByRIF(c *gin.Context) {
 		return
 	}
 
+	if !registrarConsulta(c, personaIDs(empresa.Socios, empresa.Empleados)...) {
+		return
+	}
+
 	c.JSON(http.StatusOK, empresa)
 }
 
@@ -162,7 +170,10 @@ func DeleteEmpresa(c *gin.Context) {
 		return
 	}
 
-	configs.DB.Delete(&empresa)
+	if err := eliminarEntidad(c, &models.Empresa{}, "empresas", empresa.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar la empresa"})
+		return
+	}
 
 	c.Status(http.StatusNoContent)
 }
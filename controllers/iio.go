@@ -223,7 +223,7 @@ func DeleteIIO(c *gin.Context) {
 		return
 	}
 
-	if err := configs.DB.Delete(&iio).Error; err != nil {
+	if err := eliminarEntidad(c, &models.IIO{}, "iios", iio.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
 		return
 	}
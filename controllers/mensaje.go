@@ -203,7 +203,7 @@ func DeleteMensaje(c *gin.Context) {
 		return
 	}
 
-	if err := configs.DB.Delete(&mensaje).Error; err != nil {
+	if err := eliminarEntidad(c, &models.Mensaje{}, "mensajes", mensaje.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
 		return
 	}
@@ -150,7 +150,7 @@ func DeleteModalidad(c *gin.Context) {
 		return
 	}
 
-	if err := configs.DB.Delete(&modalidad).Error; err != nil {
+	if err := eliminarEntidad(c, &models.Modalidad{}, "modalidades", modalidad.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar la modalidad"})
 		return
 	}
@@ -202,13 +202,7 @@ func DeletePasaporte(c *gin.Context) {
 		return
 	}
 
-	// Eliminar la foto asociada si existe
-	if pasaporte.Foto != "" {
-		filepath := filepath.Join("static", pasaporte.Foto)
-		os.Remove(filepath)
-	}
-
-	if err := configs.DB.Delete(&pasaporte).Error; err != nil {
+	if err := eliminarEntidad(c, &models.Pasaporte{}, "pasaportes", pasaporte.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar el pasaporte"})
 		return
 	}
@@ -11,6 +11,7 @@ import (
 	"github.com/google/uuid"
 	configs "github.com/oficialrivas/sgi/config"
 	"github.com/oficialrivas/sgi/models"
+	"gorm.io/gorm"
 )
 
 // CreatePersona crea un nuevo registro de Persona
@@ -58,7 +59,13 @@ func CreatePersona(c *gin.Context) {
 	// Asignar el área del usuario desde el token al correo
 	persona.Area = claims.Area
 
-	if err := configs.DB.Create(&persona).Error; err != nil {
+	err = configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&persona).Error; err != nil {
+			return err
+		}
+		return registrarAuditoria(tx, c, models.AccionCrear, "personas", persona.ID)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
 		return
 	}
@@ -86,6 +93,10 @@ func GetPersona(c *gin.Context) {
 		return
 	}
 
+	if !registrarConsulta(c, persona.ID) {
+		return
+	}
+
 	c.JSON(http.StatusOK, persona)
 }
 
@@ -119,7 +130,13 @@ func UpdatePersona(c *gin.Context) {
 
 	persona.ID, _ = uuid.Parse(id)
 	persona.UpdatedAt = time.Now().UTC()
-	if err := configs.DB.Save(&persona).Error; err != nil {
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&persona).Error; err != nil {
+			return err
+		}
+		return registrarAuditoria(tx, c, models.AccionCorregir, "personas", persona.ID)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
 		return
 	}
@@ -147,7 +164,7 @@ func DeletePersona(c *gin.Context) {
 		return
 	}
 
-	if err := configs.DB.Delete(&persona).Error; err != nil {
+	if err := eliminarEntidad(c, &models.Persona{}, "personas", persona.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
 		return
 	}
@@ -175,6 +192,10 @@ func GetPersonaByCedula(c *gin.Context) {
 		return
 	}
 
+	if !registrarConsulta(c, persona.ID) {
+		return
+	}
+
 	c.JSON(http.StatusOK, persona)
 }
 
@@ -198,6 +219,10 @@ func GetPersonaByPasaporte(c *gin.Context) {
 		return
 	}
 
+	if !registrarConsulta(c, persona.ID) {
+		return
+	}
+
 	c.JSON(http.StatusOK, persona)
 }
 
@@ -221,6 +246,10 @@ func GetPersonaByNombre(c *gin.Context) {
 		return
 	}
 
+	if !registrarConsulta(c, persona.ID) {
+		return
+	}
+
 	c.JSON(http.StatusOK, persona)
 }
 
@@ -244,6 +273,10 @@ func GetPersonasByNacionalidad(c *gin.Context) {
 		return
 	}
 
+	if !registrarConsulta(c, personaIDs(personas)...) {
+		return
+	}
+
 	c.JSON(http.StatusOK, personas)
 }
 
@@ -265,6 +298,10 @@ func GetPersonas(c *gin.Context) {
 		return
 	}
 
+	if !registrarConsulta(c, personaIDs(personas)...) {
+		return
+	}
+
 	c.JSON(http.StatusOK, personas)
 }
 
@@ -294,6 +331,10 @@ func GetPersonasByCedula(c *gin.Context) {
 		return
 	}
 
+	if !registrarConsulta(c, personaIDs(personas)...) {
+		return
+	}
+
 	c.JSON(http.StatusOK, personas)
 }
 
@@ -335,5 +376,9 @@ func SearchPersonas(c *gin.Context) {
 	// Log resultado de la búsqueda
 	log.Printf("Found %d personas", len(personas))
 
+	if !registrarConsulta(c, personaIDs(personas)...) {
+		return
+	}
+
 	c.JSON(http.StatusOK, personas)
 }
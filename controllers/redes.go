@@ -148,7 +148,7 @@ func DeleteRedes(c *gin.Context) {
 		return
 	}
 
-	if err := configs.DB.Delete(&redes).Error; err != nil {
+	if err := eliminarEntidad(c, &models.Redes{}, "redes", redes.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar la red"})
 		return
 	}
@@ -153,7 +153,7 @@ func DeleteTie(c *gin.Context) {
 		return
 	}
 
-	if err := configs.DB.Delete(&tie).Error; err != nil {
+	if err := eliminarEntidad(c, &models.Tie{}, "ties", tie.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar la TIE"})
 		return
 	}
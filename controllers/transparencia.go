@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	configs "github.com/oficialrivas/sgi/config"
+	"github.com/oficialrivas/sgi/models"
+	"github.com/oficialrivas/sgi/utils"
+)
+
+// GenerateTransparencyReport genera un reporte firmado del uso del sistema en un período
+// @Summary Genera un reporte de transparencia firmado
+// @Description Cuenta, según el audit log, las personas creadas, consultadas, corregidas y eliminadas y los accesos temporales otorgados en el período. Las métricas que el sistema no registra quedan en null y se explican en no_registrado. El reporte se firma con Ed25519.
+// @Tags transparencia
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param request body PeriodRequestParams true "Período del reporte (YYYY-MM-DD, ambos días incluidos)"
+// @Success 200 {object} models.SignedTransparencyReport
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /transparencia/reporte [post]
+// @Security ApiKeyAuth
+func GenerateTransparencyReport(c *gin.Context) {
+	var params PeriodRequestParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	startDate, err := time.Parse("2006-01-02", params.StartDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Formato de fecha inválido para start_date"})
+		return
+	}
+
+	endDate, err := time.Parse("2006-01-02", params.EndDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Formato de fecha inválido para end_date"})
+		return
+	}
+
+	if startDate.After(endDate) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "start_date no puede ser posterior a end_date"})
+		return
+	}
+
+	// El día final se incluye completo
+	until := endDate.AddDate(0, 0, 1)
+
+	report := models.TransparencyReport{
+		Desde:      startDate,
+		Hasta:      endDate,
+		GeneradoEn: time.Now().UTC().Truncate(time.Second),
+		NoRegistrado: map[string]string{
+			"solicitudes_atendidas": "subject requests are not tracked by the system",
+		},
+	}
+
+	// Todo se cuenta desde el audit log, que no cambia al borrar o corregir datos,
+	// para que el reporte de un período se pueda reproducir más adelante
+	counts := []struct {
+		entityType string
+		accion     string
+		total      *int64
+	}{
+		{"personas", models.AccionCrear, &report.PersonasCreadas},
+		{"personas", models.AccionConsultar, &report.PersonasConsultadas},
+		{"personas", models.AccionCorregir, &report.PersonasCorregidas},
+		{"personas", models.AccionEliminar, &report.PersonasEliminadas},
+		{models.TemporaryAccess{}.TableName(), models.AccionCrear, &report.AccesosTemporales},
+	}
+	for _, count := range counts {
+		if err := contarEntidades(count.entityType, count.accion, startDate, until, count.total); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	signature, publicKey, err := utils.SignReport(data)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SignedTransparencyReport{
+		Reporte:      data,
+		Firma:        signature,
+		ClavePublica: publicKey,
+		Algoritmo:    "Ed25519",
+	})
+}
+
+// contarEntidades cuenta las entidades distintas de entityType sobre las que se
+// registró la acción entre desde (incluido) y hasta (excluido), tanto en entradas
+// de una entidad como en las que listan varias en Detalle.
+func contarEntidades(entityType, accion string, desde, hasta time.Time, total *int64) error {
+	return configs.DB.Raw(`
+		SELECT COUNT(DISTINCT id) FROM (
+			SELECT entity_id AS id FROM audit_log
+			WHERE entity_type = @tipo AND accion = @accion AND created_at >= @desde AND created_at < @hasta AND entity_id <> @nil
+			UNION ALL
+			SELECT CAST(jsonb_array_elements_text(CAST(detalle AS jsonb)->'ids') AS uuid) FROM audit_log
+			WHERE entity_type = @tipo AND accion = @accion AND created_at >= @desde AND created_at < @hasta AND entity_id = @nil
+		) AS entidades
+	`, sql.Named("tipo", entityType), sql.Named("accion", accion), sql.Named("desde", desde), sql.Named("hasta", hasta), sql.Named("nil", uuid.Nil)).Scan(total).Error
+}
+
+// VerifyTransparencyReport comprueba la integridad de un reporte de transparencia
+// @Summary Verifica la firma de un reporte de transparencia
+// @Description Comprueba que el reporte no haya sido modificado y que lo haya firmado este servidor
+// @Tags transparencia
+// @Accept json
+// @Produce json
+// @Param request body models.SignedTransparencyReport true "Reporte firmado"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Router /transparencia/verificar [post]
+func VerifyTransparencyReport(c *gin.Context) {
+	var request models.SignedTransparencyReport
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := utils.VerifyReport(request.Reporte, request.Firma); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{Message: "Firma válida"})
+}
@@ -304,7 +304,7 @@ func DeleteUser(c *gin.Context) {
 		return
 	}
 
-	if err := configs.DB.Delete(&user).Error; err != nil {
+	if err := eliminarEntidad(c, &models.User{}, "users", user.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
 		return
 	}
@@ -966,7 +966,7 @@ func DeleteUsersByCedula(c *gin.Context) {
 		}
 
 		// Eliminar usuario existente
-		if err := configs.DB.Delete(&user).Error; err != nil {
+		if err := eliminarEntidad(c, &models.User{}, "users", user.ID); err != nil {
 			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: fmt.Sprintf("Error al eliminar el usuario: %v", err)})
 			return
 		}
@@ -80,6 +80,10 @@ func GetVehiculoByID(c *gin.Context) {
 		return
 	}
 
+	if !registrarConsulta(c, personaIDs(vehiculo.Usuarios)...) {
+		return
+	}
+
 	c.JSON(http.StatusOK, vehiculo)
 }
 
@@ -104,6 +108,10 @@ func GetVehiculoByMatricula(c *gin.Context) {
 		return
 	}
 
+	if !registrarConsulta(c, personaIDs(vehiculo.Usuarios)...) {
+		return
+	}
+
 	c.JSON(http.StatusOK, vehiculo)
 }
 
@@ -161,7 +169,10 @@ func DeleteVehiculo(c *gin.Context) {
 		return
 	}
 
-	configs.DB.Delete(&vehiculo)
+	if err := eliminarEntidad(c, &models.Vehiculo{}, "vehiculos", vehiculo.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar el vehículo"})
+		return
+	}
 
 	c.Status(http.StatusNoContent)
 }
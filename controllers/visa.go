@@ -160,7 +160,7 @@ func DeleteVisa(c *gin.Context) {
 		return
 	}
 
-	if err := configs.DB.Delete(&visa).Error; err != nil {
+	if err := eliminarEntidad(c, &models.Visa{}, "visas", visa.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar la visa"})
 		return
 	}
@@ -4559,6 +4559,104 @@ const docTemplate = `{
                 }
             }
         },
+        "/transparencia/reporte": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Cuenta, según el audit log, las personas creadas, consultadas, corregidas y eliminadas y los accesos temporales otorgados en el período. Las métricas que el sistema no registra quedan en null y se explican en no_registrado. El reporte se firma con Ed25519.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transparencia"
+                ],
+                "summary": "Genera un reporte de transparencia firmado",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Período del reporte (YYYY-MM-DD, ambos días incluidos)",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.PeriodRequestParams"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SignedTransparencyReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/transparencia/verificar": {
+            "post": {
+                "description": "Comprueba que el reporte no haya sido modificado y que lo haya firmado este servidor",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transparencia"
+                ],
+                "summary": "Verifica la firma de un reporte de transparencia",
+                "parameters": [
+                    {
+                        "description": "Reporte firmado",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SignedTransparencyReport"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/upload_users": {
             "post": {
                 "security": [
@@ -7025,6 +7123,23 @@ const docTemplate = `{
                 }
             }
         },
+        "models.SignedTransparencyReport": {
+            "type": "object",
+            "properties": {
+                "algoritmo": {
+                    "type": "string"
+                },
+                "clave_publica": {
+                    "type": "string"
+                },
+                "firma": {
+                    "type": "string"
+                },
+                "reporte": {
+                    "type": "object"
+                }
+            }
+        },
         "models.SuccessResponse": {
             "type": "object",
             "properties": {
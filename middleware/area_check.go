@@ -148,6 +148,42 @@ func AreaCheck() gin.HandlerFunc {
 				entityName = "IIO"
 			}
 
+		case "correos":
+			var entity models.Correo
+			if entityID != "" {
+				if err := configs.DB.Where("id = ?", entityID).First(&entity).Error; err != nil {
+					c.JSON(http.StatusNotFound, gin.H{"error": "Entity not found"})
+					c.Abort()
+					return
+				}
+				entityArea = entity.Area
+				entityName = "Correo"
+			}
+
+		case "redes":
+			var entity models.Redes
+			if entityID != "" {
+				if err := configs.DB.Where("id = ?", entityID).First(&entity).Error; err != nil {
+					c.JSON(http.StatusNotFound, gin.H{"error": "Entity not found"})
+					c.Abort()
+					return
+				}
+				entityArea = entity.Area
+				entityName = "Redes"
+			}
+
+		case "mensajes":
+			var entity models.Mensaje
+			if entityID != "" {
+				if err := configs.DB.Where("id = ?", entityID).First(&entity).Error; err != nil {
+					c.JSON(http.StatusNotFound, gin.H{"error": "Entity not found"})
+					c.Abort()
+					return
+				}
+				entityArea = entity.Area
+				entityName = "Mensaje"
+			}
+
 		default:
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported entity type"})
 			c.Abort()
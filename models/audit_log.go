@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Acciones registradas en el audit log
+const (
+	AccionCrear     = "crear"
+	AccionConsultar = "consultar"
+	AccionCorregir  = "corregir"
+	AccionEliminar  = "eliminar"
+	// AccionBorrarArchivos registra el resultado de borrar los archivos de una
+	// entidad eliminada
+	AccionBorrarArchivos = "borrar_archivos"
+)
+
+// AuditLog registra una acción de un usuario sobre una entidad. Cuando una
+// entrada cubre varias entidades EntityID queda en uuid.Nil y los IDs se guardan
+// en Detalle como {"ids": [...]}.
+type AuditLog struct {
+	ID         uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4()" json:"id"`
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`
+	UserID     uuid.UUID `gorm:"type:uuid;column:user_id" json:"user_id"`
+	Accion     string    `gorm:"type:varchar(20);not null" json:"accion"`
+	EntityType string    `gorm:"type:varchar(50);not null" json:"entity_type"`
+	EntityID   uuid.UUID `gorm:"type:uuid;not null;index" json:"entity_id"`
+	Detalle    string    `json:"detalle"`
+}
+
+func (AuditLog) TableName() string {
+	return "audit_log"
+}
+
+func (audit *AuditLog) BeforeCreate(tx *gorm.DB) (err error) {
+	audit.ID = uuid.New()
+	audit.CreatedAt = time.Now().UTC()
+	return nil
+}
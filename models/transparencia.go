@@ -0,0 +1,32 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// TransparencyReport resume el uso del sistema en un período para los órganos de control
+type TransparencyReport struct {
+	Desde               time.Time `json:"desde"`
+	Hasta               time.Time `json:"hasta"`
+	GeneradoEn          time.Time `json:"generado_en"`
+	PersonasCreadas     int64     `json:"personas_creadas"`
+	PersonasConsultadas int64     `json:"personas_consultadas"`
+	PersonasCorregidas  int64     `json:"personas_corregidas"`
+	PersonasEliminadas  int64     `json:"personas_eliminadas"`
+	AccesosTemporales   int64     `json:"accesos_temporales"`
+	// SolicitudesAtendidas queda en null mientras el sistema no registre las
+	// solicitudes de los titulares de los datos
+	SolicitudesAtendidas *int64 `json:"solicitudes_atendidas"`
+	// NoRegistrado indica, por campo, las métricas que el reporte no puede dar y por qué
+	NoRegistrado map[string]string `json:"no_registrado"`
+}
+
+// SignedTransparencyReport contiene el reporte serializado y su firma Ed25519.
+// La firma cubre exactamente los bytes del campo reporte.
+type SignedTransparencyReport struct {
+	Reporte      json.RawMessage `json:"reporte" swaggertype:"object"`
+	Firma        string          `json:"firma"`
+	ClavePublica string          `json:"clave_publica"`
+	Algoritmo    string          `json:"algoritmo"`
+}
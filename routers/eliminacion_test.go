@@ -0,0 +1,402 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/oficialrivas/sgi/models"
+	"github.com/oficialrivas/sgi/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// referencingColumns lista a mano, sin derivarlo del esquema de gorm, cada
+// "tabla.columna" que puede apuntar a una fila de la tabla indicada. Así un hueco
+// en el recorrido del esquema que hace la cascada no queda oculto también aquí.
+// relacion_persona la crea IIO, el primer modelo migrado que la declara, así que
+// sólo tiene iio_id y persona_id.
+var referencingColumns = map[string][]string{
+	"persona": {
+		"persona_nacionalidad.persona_id", "persona_vehiculos.persona_id", "persona_empresas.persona_id",
+		"persona_direcciones.persona_id", "persona_iio.persona_id", "persona_visa.persona_id",
+		"persona_pasaporte.persona_id", "persona_correo.persona_id", "persona_redes.persona_id",
+		"persona_relacionados.id", "relacion_persona.persona_id", "relacion_caso.persona_id",
+		"propietario_caso.persona_id", "usuario_vehiculos.persona_id", "empresa_socios.persona_id",
+		"empresa_empleados.persona_id", "direccion_usuarios.persona_id", "direccion_empleados.persona_id",
+		"empresa.representante_id", "direccion.dueno_id", "pasaporte.representante_id",
+		"visa.representante_id", "correo.dueno_id", "redes.dueno_id",
+		"temporary_access.entity_id",
+	},
+	"iio": {
+		"iio_modalidad.iio_id", "iio_ties.iio_id", "relacion_mensaje.iio_id", "relacion_persona.iio_id",
+		"persona_iio.iio_id", "vehiculo_iio.iio_id", "caso_iio.iio_id",
+		"temporary_access.entity_id",
+	},
+	"mensaje":   {"relacion_mensaje.mensaje_id", "temporary_access.entity_id"},
+	"correo":    {"persona_correo.correo_id", "temporary_access.entity_id"},
+	"redes":     {"persona_redes.redes_id", "temporary_access.entity_id"},
+	"pasaporte": {"persona_pasaporte.pasaporte_id", "temporary_access.entity_id"},
+	"user": {
+		"caso_users.user_id", "temporary_access.user_id",
+		"caso.user_id", "correo.user_id", "direccion.user_id", "documento.user_id", "empresa.user_id",
+		"iio.user_id", "mensaje.user_id", "modalidad.user_id", "nacionalidad.user_id", "pasaporte.user_id",
+		"persona.user_id", "redes.user_id", "tie.user_id", "vehiculo.user_id", "visa.user_id",
+	},
+}
+
+// assertNoReferences falla si alguna columna de referencingColumns sigue
+// apuntando a la fila eliminada de table.
+func assertNoReferences(t *testing.T, db *gorm.DB, table string, id uuid.UUID) {
+	t.Helper()
+	columns, ok := referencingColumns[table]
+	if !ok {
+		t.Fatalf("no referencing columns listed for table %s", table)
+	}
+	for _, ref := range columns {
+		parts := strings.SplitN(ref, ".", 2)
+		var n int64
+		err := db.Table(clause.Table{Name: parts[0]}.Name).
+			Where(clause.Eq{Column: clause.Column{Name: parts[1]}, Value: id}).
+			Count(&n).Error
+		if err != nil {
+			t.Fatalf("count %s: %v", ref, err)
+		}
+		if n > 0 {
+			t.Errorf("%d rows in %s still reference deleted %s %s", n, ref, table, id)
+		}
+	}
+}
+
+func assertExists(t *testing.T, db *gorm.DB, value interface{}, id uuid.UUID, want bool) {
+	t.Helper()
+	var n int64
+	if err := db.Model(value).Where("id = ?", id).Count(&n).Error; err != nil {
+		t.Fatalf("count %T: %v", value, err)
+	}
+	if (n > 0) != want {
+		t.Errorf("%T %s exists = %v, want %v", value, id, n > 0, want)
+	}
+}
+
+// auditDetail devuelve el Detalle de la entrada del audit log con esa acción sobre la entidad
+func auditDetail(t *testing.T, db *gorm.DB, accion, entityType string, id uuid.UUID) map[string]interface{} {
+	t.Helper()
+	var entry models.AuditLog
+	if err := db.Where("accion = ? AND entity_type = ? AND entity_id = ?", accion, entityType, id).First(&entry).Error; err != nil {
+		t.Fatalf("%s audit entry for %s %s not found: %v", accion, entityType, id, err)
+	}
+	var detail map[string]interface{}
+	if err := json.Unmarshal([]byte(entry.Detalle), &detail); err != nil {
+		t.Fatalf("decode audit detail: %v", err)
+	}
+	return detail
+}
+
+// TestDeleteCascade borra entidades con asociaciones a través de la API y
+// comprueba que no quedan referencias huérfanas, que los accesos temporales se
+// conservan anonimizados y que el audit log refleja lo que pasó con los archivos.
+func TestDeleteCascade(t *testing.T) {
+	requireDB(t)
+
+	// Los archivos subidos viven en static/ relativo al directorio de trabajo
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	for _, sub := range []string{"static", "otros"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	admin := fixtures.users[areaA]["admin"]
+	token, _, err := utils.GenerateTokens(admin.ID.String(), admin.Nivel, admin.Area)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newRouter(func(c *gin.Context) {})
+
+	del := func(path string) int {
+		req := httptest.NewRequest(http.MethodDelete, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	withRollback(t, func(tx *gorm.DB) {
+		create := func(value interface{}) {
+			t.Helper()
+			if err := tx.Omit(clause.Associations).Create(value).Error; err != nil {
+				t.Fatalf("create %T: %v", value, err)
+			}
+		}
+		link := func(table string, row map[string]interface{}) {
+			t.Helper()
+			if err := tx.Table(table).Create(row).Error; err != nil {
+				t.Fatalf("link %s: %v", table, err)
+			}
+		}
+		writeFile := func(path string) string {
+			t.Helper()
+			if err := os.WriteFile(path, []byte("contenido"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			return path
+		}
+		count := func(table, where string, args ...interface{}) int64 {
+			t.Helper()
+			var n int64
+			if err := tx.Table(table).Where(where, args...).Count(&n).Error; err != nil {
+				t.Fatalf("count %s: %v", table, err)
+			}
+			return n
+		}
+
+		usuario := models.User{Nombre: "Borrar", Cedula: "V-50000001", Telefono: "04140000001", Correo: "borrar.user@sgi.test", Area: areaA, Nivel: "analyst"}
+		create(&usuario)
+
+		persona := models.Persona{Nombre: "Borrar", Cedula: "V-10000001", Area: areaA}
+		testigo := models.Persona{Nombre: "Testigo", Cedula: "V-10000002", Area: areaA, UserID: usuario.ID}
+		create(&persona)
+		create(&testigo)
+
+		vehiculo := models.Vehiculo{Matricula: "BR001AA", Area: areaA}
+		caso := models.Caso{Nombre: "Caso borrado", Codigo: "C-BORRAR", Area: areaA}
+		empresa := models.Empresa{Nombre: "Empresa", RIF: "J-10000001", Area: areaA, RepresentanteID: persona.ID}
+		correo := models.Correo{Direccion: "borrar@sgi.test", Area: areaA, DuenoID: persona.ID}
+		redes := models.Redes{Direccion: "@borrar", Area: areaA, DuenoID: persona.ID}
+		pasaporte := models.Pasaporte{Codigo: "P-BORRAR", Foto: "pasaporte.jpg", Area: areaA, RepresentanteID: persona.ID}
+		iio := models.IIO{Nombre: "IIO borrado", Fecha: time.Now(), Area: areaA, ImagenURL: "static/iio.jpg"}
+		mensaje := models.Mensaje{Nombre: "Mensaje borrado", Fecha: time.Now(), Area: areaA}
+		for _, value := range []interface{}{&vehiculo, &caso, &empresa, &correo, &redes, &pasaporte, &iio, &mensaje} {
+			create(value)
+		}
+		fotoPasaporte := writeFile(filepath.Join("static", "pasaporte.jpg"))
+		imagenIIO := writeFile(filepath.Join("static", "iio.jpg"))
+
+		link("persona_vehiculos", map[string]interface{}{"persona_id": persona.ID, "vehiculo_id": vehiculo.ID})
+		link("propietario_caso", map[string]interface{}{"vehiculo_id": vehiculo.ID, "persona_id": persona.ID})
+		link("relacion_caso", map[string]interface{}{"caso_id": caso.ID, "persona_id": persona.ID})
+		link("empresa_socios", map[string]interface{}{"empresa_id": empresa.ID, "persona_id": persona.ID})
+		link("persona_correo", map[string]interface{}{"persona_id": persona.ID, "correo_id": correo.ID})
+		link("persona_redes", map[string]interface{}{"persona_id": persona.ID, "redes_id": redes.ID})
+		link("persona_iio", map[string]interface{}{"persona_id": persona.ID, "iio_id": iio.ID})
+		// persona_relacionados guarda ambos lados de la relación en la misma columna id
+		link("persona_relacionados", map[string]interface{}{"id": persona.ID})
+		link("persona_relacionados", map[string]interface{}{"id": testigo.ID})
+		link("relacion_persona", map[string]interface{}{"iio_id": iio.ID, "persona_id": persona.ID})
+		link("relacion_persona", map[string]interface{}{"iio_id": iio.ID, "persona_id": testigo.ID})
+		link("caso_iio", map[string]interface{}{"caso_id": caso.ID, "iio_id": iio.ID})
+		link("vehiculo_iio", map[string]interface{}{"vehiculo_id": vehiculo.ID, "iio_id": iio.ID})
+		link("relacion_mensaje", map[string]interface{}{"iio_id": iio.ID, "mensaje_id": mensaje.ID})
+		link("caso_users", map[string]interface{}{"caso_id": caso.ID, "user_id": usuario.ID})
+
+		accesoPersona := models.TemporaryAccess{UserID: fixtures.users[areaB]["analyst"].ID, EntityID: persona.ID, EntityType: "personas", ExpiresAt: time.Now().Add(time.Hour)}
+		accesoUsuario := models.TemporaryAccess{UserID: usuario.ID, EntityID: caso.ID, EntityType: "casos", ExpiresAt: time.Now().Add(time.Hour)}
+		create(&accesoPersona)
+		create(&accesoUsuario)
+
+		// assertGrantAnonymized comprueba que el acceso sigue existiendo, vencido
+		assertGrantAnonymized := func(t *testing.T, id uuid.UUID) {
+			t.Helper()
+			var access models.TemporaryAccess
+			if err := tx.First(&access, "id = ?", id).Error; err != nil {
+				t.Fatalf("temporary access %s was deleted instead of anonymized: %v", id, err)
+			}
+			if access.ExpiresAt.After(time.Now()) {
+				t.Errorf("temporary access %s still expires at %v", id, access.ExpiresAt)
+			}
+		}
+
+		t.Run("persona", func(t *testing.T) {
+			if status := del("/personas/" + persona.ID.String()); status != http.StatusNoContent {
+				t.Fatalf("DELETE /personas/:id returned %d", status)
+			}
+
+			assertNoReferences(t, tx, "persona", persona.ID)
+			assertNoReferences(t, tx, "correo", correo.ID)
+			assertNoReferences(t, tx, "redes", redes.ID)
+			assertNoReferences(t, tx, "pasaporte", pasaporte.ID)
+			assertExists(t, tx, &models.Persona{}, persona.ID, false)
+			assertExists(t, tx, &models.Correo{}, correo.ID, false)
+			assertExists(t, tx, &models.Redes{}, redes.ID, false)
+			assertExists(t, tx, &models.Pasaporte{}, pasaporte.ID, false)
+			// Las entidades relacionadas que no pertenecen a la persona se conservan
+			assertExists(t, tx, &models.Empresa{}, empresa.ID, true)
+			assertExists(t, tx, &models.Vehiculo{}, vehiculo.ID, true)
+			assertExists(t, tx, &models.Caso{}, caso.ID, true)
+			if n := count("persona_relacionados", "id = ?", testigo.ID); n != 1 {
+				t.Errorf("persona_relacionados rows for the other persona = %d, want 1", n)
+			}
+			if n := count("relacion_persona", "persona_id = ?", testigo.ID); n != 1 {
+				t.Errorf("relacion_persona rows for the other persona = %d, want 1", n)
+			}
+			assertGrantAnonymized(t, accesoPersona.ID)
+
+			if _, err := os.Stat(fotoPasaporte); !os.IsNotExist(err) {
+				t.Errorf("passport photo was not scrubbed: %v", err)
+			}
+
+			cert := auditDetail(t, tx, models.AccionEliminar, "personas", persona.ID)
+			eliminadas, _ := cert["filas_eliminadas"].(map[string]interface{})
+			for _, table := range []string{
+				"persona", "persona_vehiculos", "propietario_caso", "relacion_caso", "empresa_socios",
+				"persona_correo", "persona_redes", "persona_iio", "persona_relacionados", "relacion_persona",
+				"correo", "redes", "pasaporte",
+			} {
+				if eliminadas[table] == nil {
+					t.Errorf("deletion certificate does not list %s: %v", table, eliminadas)
+				}
+			}
+			desvinculadas, _ := cert["filas_desvinculadas"].(map[string]interface{})
+			for _, ref := range []string{"empresa.representante_id", "temporary_access.entity_id"} {
+				if desvinculadas[ref] == nil {
+					t.Errorf("deletion certificate does not list %s: %v", ref, desvinculadas)
+				}
+			}
+
+			// El certificado sólo anota los archivos pendientes; el resultado va aparte
+			foto := filepath.Join("static", "pasaporte.jpg")
+			if pendientes, _ := cert["archivos_por_borrar"].([]interface{}); len(pendientes) != 1 || pendientes[0] != foto {
+				t.Errorf("archivos_por_borrar = %v, want [%s]", cert["archivos_por_borrar"], foto)
+			}
+			scrub := auditDetail(t, tx, models.AccionBorrarArchivos, "personas", persona.ID)
+			if borrados, _ := scrub["archivos_borrados"].([]interface{}); len(borrados) != 1 || borrados[0] != foto {
+				t.Errorf("archivos_borrados = %v, want [%s]", scrub["archivos_borrados"], foto)
+			}
+		})
+
+		t.Run("iio", func(t *testing.T) {
+			if status := del("/iios/" + iio.ID.String()); status != http.StatusNoContent {
+				t.Fatalf("DELETE /iios/:id returned %d", status)
+			}
+
+			assertNoReferences(t, tx, "iio", iio.ID)
+			assertExists(t, tx, &models.IIO{}, iio.ID, false)
+			assertExists(t, tx, &models.Mensaje{}, mensaje.ID, true)
+			assertExists(t, tx, &models.Persona{}, testigo.ID, true)
+			assertExists(t, tx, &models.Vehiculo{}, vehiculo.ID, true)
+
+			if _, err := os.Stat(imagenIIO); !os.IsNotExist(err) {
+				t.Errorf("IIO image was not scrubbed: %v", err)
+			}
+			cert := auditDetail(t, tx, models.AccionEliminar, "iios", iio.ID)
+			if pendientes, _ := cert["archivos_por_borrar"].([]interface{}); len(pendientes) != 1 {
+				t.Errorf("archivos_por_borrar = %v, want the IIO image", cert["archivos_por_borrar"])
+			}
+			scrub := auditDetail(t, tx, models.AccionBorrarArchivos, "iios", iio.ID)
+			if borrados, _ := scrub["archivos_borrados"].([]interface{}); len(borrados) != 1 {
+				t.Errorf("archivos_borrados = %v, want the IIO image", scrub["archivos_borrados"])
+			}
+		})
+
+		t.Run("mensaje", func(t *testing.T) {
+			otro := models.IIO{Nombre: "IIO con mensaje", Fecha: time.Now(), Area: areaA}
+			create(&otro)
+			link("relacion_mensaje", map[string]interface{}{"iio_id": otro.ID, "mensaje_id": mensaje.ID})
+
+			if status := del("/mensajes/" + mensaje.ID.String()); status != http.StatusNoContent {
+				t.Fatalf("DELETE /mensajes/:id returned %d", status)
+			}
+
+			assertNoReferences(t, tx, "mensaje", mensaje.ID)
+			assertExists(t, tx, &models.Mensaje{}, mensaje.ID, false)
+			assertExists(t, tx, &models.IIO{}, otro.ID, true)
+			auditDetail(t, tx, models.AccionEliminar, "mensajes", mensaje.ID)
+		})
+
+		t.Run("user", func(t *testing.T) {
+			if status := del("/users/" + usuario.ID.String()); status != http.StatusOK {
+				t.Fatalf("DELETE /users/:id returned %d", status)
+			}
+
+			assertNoReferences(t, tx, "user", usuario.ID)
+			assertExists(t, tx, &models.User{}, usuario.ID, false)
+			assertExists(t, tx, &models.Caso{}, caso.ID, true)
+			assertExists(t, tx, &models.Persona{}, testigo.ID, true)
+			assertGrantAnonymized(t, accesoUsuario.ID)
+
+			cert := auditDetail(t, tx, models.AccionEliminar, "users", usuario.ID)
+			desvinculadas, _ := cert["filas_desvinculadas"].(map[string]interface{})
+			for _, ref := range []string{"persona.user_id", "temporary_access.user_id"} {
+				if desvinculadas[ref] == nil {
+					t.Errorf("deletion certificate does not list %s: %v", ref, desvinculadas)
+				}
+			}
+		})
+
+		t.Run("shared image", func(t *testing.T) {
+			compartida := writeFile(filepath.Join("static", "image.jpg"))
+			primera := models.IIO{Nombre: "IIO compartida 1", Fecha: time.Now(), Area: areaA, ImagenURL: "static/image.jpg"}
+			segunda := models.IIO{Nombre: "IIO compartida 2", Fecha: time.Now(), Area: areaA, ImagenURL: "static/image.jpg"}
+			create(&primera)
+			create(&segunda)
+
+			if status := del("/iios/" + primera.ID.String()); status != http.StatusNoContent {
+				t.Fatalf("DELETE /iios/:id returned %d", status)
+			}
+			if data, err := os.ReadFile(compartida); err != nil || string(data) != "contenido" {
+				t.Errorf("image still used by another IIO was scrubbed: %q, %v", data, err)
+			}
+			cert := auditDetail(t, tx, models.AccionEliminar, "iios", primera.ID)
+			if compartidos, _ := cert["archivos_conservados_compartidos"].([]interface{}); len(compartidos) != 1 || compartidos[0] != compartida {
+				t.Errorf("archivos_conservados_compartidos = %v, want [%s]", cert["archivos_conservados_compartidos"], compartida)
+			}
+			if pendientes, _ := cert["archivos_por_borrar"].([]interface{}); len(pendientes) != 0 {
+				t.Errorf("archivos_por_borrar = %v, want none", pendientes)
+			}
+			var n int64
+			tx.Model(&models.AuditLog{}).Where("accion = ? AND entity_id = ?", models.AccionBorrarArchivos, primera.ID).Count(&n)
+			if n != 0 {
+				t.Errorf("%d borrar_archivos entries for an IIO whose image is shared, want none", n)
+			}
+
+			// Al borrar el último registro que la usa, la imagen sí se borra
+			if status := del("/iios/" + segunda.ID.String()); status != http.StatusNoContent {
+				t.Fatalf("DELETE /iios/:id returned %d", status)
+			}
+			if _, err := os.Stat(compartida); !os.IsNotExist(err) {
+				t.Errorf("image of the last IIO using it was not scrubbed: %v", err)
+			}
+			scrub := auditDetail(t, tx, models.AccionBorrarArchivos, "iios", segunda.ID)
+			if borrados, _ := scrub["archivos_borrados"].([]interface{}); len(borrados) != 1 || borrados[0] != compartida {
+				t.Errorf("archivos_borrados = %v, want [%s]", scrub["archivos_borrados"], compartida)
+			}
+		})
+
+		t.Run("file outside static", func(t *testing.T) {
+			fuera := writeFile(filepath.Join("otros", "iio.jpg"))
+			otro := models.IIO{Nombre: "IIO fuera", Fecha: time.Now(), Area: areaA, ImagenURL: "otros/iio.jpg"}
+			create(&otro)
+
+			if status := del("/iios/" + otro.ID.String()); status != http.StatusNoContent {
+				t.Fatalf("DELETE /iios/:id returned %d", status)
+			}
+
+			if _, err := os.Stat(fuera); err != nil {
+				t.Errorf("file outside static was touched: %v", err)
+			}
+			scrub := auditDetail(t, tx, models.AccionBorrarArchivos, "iios", otro.ID)
+			if fallidos, _ := scrub["archivos_fallidos"].(map[string]interface{}); fallidos[filepath.FromSlash("otros/iio.jpg")] == nil {
+				t.Errorf("archivos_fallidos = %v, want the refused file", scrub["archivos_fallidos"])
+			}
+			if borrados, _ := scrub["archivos_borrados"].([]interface{}); len(borrados) != 0 {
+				t.Errorf("archivos_borrados = %v, want none", borrados)
+			}
+		})
+	})
+}
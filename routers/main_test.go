@@ -0,0 +1,273 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	configs "github.com/oficialrivas/sgi/config"
+	"github.com/oficialrivas/sgi/models"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+)
+
+// errNoPostgres indica que no hay binarios de Postgres en la máquina; las
+// pruebas que necesitan base de datos se omiten en lugar de fallar.
+var errNoPostgres = errors.New("postgres binaries (initdb, pg_ctl) not found")
+
+// dbErr guarda el resultado de levantar la base de datos desechable.
+var dbErr error
+
+// fixtures contiene los datos sembrados para la matriz de autorización.
+var fixtures *seedData
+
+type seedData struct {
+	// users[area][rol] es un usuario de ese nivel y área
+	users map[string]map[string]models.User
+	// entities[segmento] es el ID de una entidad sembrada en areaA,
+	// indexada por el primer segmento de la ruta ("personas", "casos"...)
+	entities map[string]uuid.UUID
+}
+
+const (
+	areaA = "SEP"
+	areaB = "TIC"
+)
+
+var allRoles = []string{"admin", "superuser", "analyst", "user"}
+
+// blockedTransport evita que los handlers que notifican a servicios externos
+// (Telegram, SMS, webhooks) salgan a la red durante las pruebas.
+type blockedTransport struct{}
+
+func (blockedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, fmt.Errorf("outbound request to %s blocked in tests", req.URL.Host)
+}
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	http.DefaultTransport = blockedTransport{}
+
+	stop, err := startPostgres()
+	if err == nil {
+		err = connectAndSeed()
+	}
+	if err != nil && !errors.Is(err, errNoPostgres) {
+		log.Printf("Failed to prepare test database: %v", err)
+	}
+	dbErr = err
+
+	code := m.Run()
+	if stop != nil {
+		stop()
+	}
+	os.Exit(code)
+}
+
+// requireDB omite la prueba si no hay Postgres disponible y la hace fallar si
+// lo hay pero no se pudo preparar.
+func requireDB(t *testing.T) {
+	t.Helper()
+	if errors.Is(dbErr, errNoPostgres) {
+		t.Skip(dbErr)
+	}
+	if dbErr != nil {
+		t.Fatalf("test database not available: %v", dbErr)
+	}
+}
+
+// findPostgresBinary busca un binario de Postgres en el PATH y en las rutas de
+// instalación habituales.
+func findPostgresBinary(name string) (string, error) {
+	if path, err := exec.LookPath(name); err == nil {
+		return path, nil
+	}
+	matches, _ := filepath.Glob(filepath.Join("/usr/lib/postgresql", "*", "bin", name))
+	matches2, _ := filepath.Glob(filepath.Join("/usr/local/pgsql", "bin", name))
+	matches = append(matches, matches2...)
+	if len(matches) == 0 {
+		return "", errNoPostgres
+	}
+	sort.Strings(matches)
+	return matches[len(matches)-1], nil
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// startPostgres levanta un clúster de Postgres en un directorio temporal y
+// exporta las variables DB_* que usa configs.ConnectToDB. La función devuelta
+// detiene el servidor y borra el directorio.
+func startPostgres() (func(), error) {
+	initdb, err := findPostgresBinary("initdb")
+	if err != nil {
+		return nil, err
+	}
+	pgCtl, err := findPostgresBinary("pg_ctl")
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "sgi-pg-")
+	if err != nil {
+		return nil, err
+	}
+	data := filepath.Join(dir, "data")
+
+	out, err := exec.Command(initdb, "-D", data, "-U", "postgres", "-A", "trust", "-E", "UTF8", "--no-locale").CombinedOutput()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("initdb: %v: %s", err, out)
+	}
+
+	port, err := freePort()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	opts := fmt.Sprintf("-p %d -k %s -c listen_addresses=127.0.0.1 -F", port, dir)
+	out, err = exec.Command(pgCtl, "-D", data, "-o", opts, "-l", filepath.Join(dir, "postgres.log"), "-w", "start").CombinedOutput()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("pg_ctl start: %v: %s", err, out)
+	}
+
+	stop := func() {
+		exec.Command(pgCtl, "-D", data, "-m", "immediate", "stop").Run()
+		os.RemoveAll(dir)
+	}
+
+	os.Setenv("DB_HOST", "127.0.0.1")
+	os.Setenv("DB_PORT", fmt.Sprint(port))
+	os.Setenv("DB_USER", "postgres")
+	os.Setenv("DB_PASSWORD", "postgres")
+	os.Setenv("DB_NAME", "postgres")
+	os.Setenv("DB_SSLMODE", "disable")
+
+	return stop, nil
+}
+
+// connectAndSeed migra el esquema con configs.ConnectToDB y siembra usuarios
+// de cada nivel en dos áreas y una entidad de cada tipo en areaA.
+func connectAndSeed() error {
+	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
+		os.Getenv("DB_HOST"), os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"),
+		os.Getenv("DB_NAME"), os.Getenv("DB_PORT"), os.Getenv("DB_SSLMODE"))
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return err
+	}
+	// Los modelos usan uuid_generate_v4() como valor por defecto
+	if err := db.Exec(`CREATE EXTENSION IF NOT EXISTS "uuid-ossp"`).Error; err != nil {
+		return err
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
+	}
+
+	configs.ConnectToDB()
+	configs.DB = configs.DB.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Silent)})
+	db = configs.DB
+
+	seed := &seedData{
+		users:    map[string]map[string]models.User{},
+		entities: map[string]uuid.UUID{},
+	}
+
+	n := 0
+	for _, area := range []string{areaA, areaB} {
+		seed.users[area] = map[string]models.User{}
+		for _, rol := range allRoles {
+			n++
+			user := models.User{
+				Nombre:   rol,
+				Apellido: area,
+				Cedula:   fmt.Sprintf("V-%08d", n),
+				Telefono: fmt.Sprintf("0414%07d", n),
+				Correo:   fmt.Sprintf("%s.%d@sgi.test", rol, n),
+				REDI:     "CAPITAL",
+				Area:     area,
+				Nivel:    rol,
+			}
+			if err := db.Create(&user).Error; err != nil {
+				return fmt.Errorf("seed user %s/%s: %w", area, rol, err)
+			}
+			seed.users[area][rol] = user
+		}
+	}
+	owner := seed.users[areaA]["user"].ID
+
+	persona := models.Persona{Nombre: "Persona", Apellido: "Prueba", Cedula: "V-99999999", Area: areaA, UserID: owner}
+	if err := db.Create(&persona).Error; err != nil {
+		return fmt.Errorf("seed persona: %w", err)
+	}
+	seed.entities["personas"] = persona.ID
+
+	caso := models.Caso{Nombre: "Caso prueba", Codigo: "C-0001", Area: areaA, UserID: owner}
+	documento := models.Documento{Nombre: "Documento prueba", Codigo: "D-0001", Area: areaA, UserID: owner}
+	pasaporte := models.Pasaporte{Numero: "P-0001", Codigo: "P-0001", Area: areaA, RepresentanteID: persona.ID, UserID: owner}
+	vehiculo := models.Vehiculo{Matricula: "AB123CD", Area: areaA, UserID: owner}
+	empresa := models.Empresa{Nombre: "Empresa prueba", RIF: "J-00000001", Area: areaA, RepresentanteID: persona.ID, UserID: owner}
+	direccion := models.Direccion{Nombre: "Direccion prueba", Area: areaA, DuenoID: persona.ID, UserID: owner}
+	visa := models.Visa{Codigo: "VS-0001", Area: areaA, RepresentanteID: persona.ID, UserID: owner}
+	iio := models.IIO{Nombre: "IIO prueba", Fecha: time.Now(), Area: areaA, UserID: owner}
+	correo := models.Correo{Direccion: "persona@sgi.test", Area: areaA, DuenoID: persona.ID, UserID: owner}
+	redes := models.Redes{Direccion: "@persona", Area: areaA, DuenoID: persona.ID, UserID: owner}
+	mensaje := models.Mensaje{Nombre: "Mensaje prueba", Fecha: time.Now(), Area: areaA, UserID: owner}
+	tie := models.Tie{Nombre: "TIE prueba", Area: areaA, UserID: owner}
+	modalidad := models.Modalidad{Nombre: "Modalidad prueba", Area: areaA, UserID: owner}
+
+	entities := []struct {
+		segment string
+		value   interface{}
+		id      *uuid.UUID
+	}{
+		{"casos", &caso, &caso.ID},
+		{"documentos", &documento, &documento.ID},
+		{"pasaportes", &pasaporte, &pasaporte.ID},
+		{"vehiculos", &vehiculo, &vehiculo.ID},
+		{"empresas", &empresa, &empresa.ID},
+		{"direcciones", &direccion, &direccion.ID},
+		{"visas", &visa, &visa.ID},
+		{"iios", &iio, &iio.ID},
+		{"correos", &correo, &correo.ID},
+		{"redes", &redes, &redes.ID},
+		{"mensajes", &mensaje, &mensaje.ID},
+		{"ties", &tie, &tie.ID},
+		{"modalidades", &modalidad, &modalidad.ID},
+	}
+	for _, e := range entities {
+		// Omitir asociaciones para no crear Representante/Dueno vacíos
+		if err := db.Omit(clause.Associations).Create(e.value).Error; err != nil {
+			return fmt.Errorf("seed %s: %w", e.segment, err)
+		}
+		seed.entities[e.segment] = *e.id
+	}
+
+	// Las rutas de usuarios y de envío de mensajes reciben el ID de un usuario
+	seed.entities["users"] = owner
+	seed.entities["send-mensaje-to-user"] = owner
+	seed.entities["send_telegram"] = owner
+
+	fixtures = seed
+	return nil
+}
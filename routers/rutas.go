@@ -19,6 +19,7 @@ func SetupRouter(r *gin.Engine) {
 	r.POST("/gestion/user", controllers.GetRecordsByUserAndPeriod)
     r.POST("/gestion/user-area-modalidad", controllers.GetRecordsCountByUserAndModalidad)
 	r.POST("/generate-token", controllers.GenerateToken) 
+	r.POST("/transparencia/verificar", controllers.VerifyTransparencyReport)
 			
 	// Endpoints protegidos con JWT
 	protected := r.Group("/")
@@ -120,6 +121,9 @@ func SetupRouter(r *gin.Engine) {
 		protected.PUT("/configuracion/area", middleware.RoleRequired("admin"), controllers.UpdateArea)
 		protected.DELETE("/configuracion/area", middleware.RoleRequired("admin"), controllers.RemoveArea)
 
+		// Reportes de transparencia
+		protected.POST("/transparencia/reporte", middleware.RoleRequired("admin"), controllers.GenerateTransparencyReport)
+
 		// CRUD para Correo
 		protected.POST("/correos", middleware.RoleRequired("admin", "superuser", "user"), controllers.CreateCorreo)
 		protected.GET("/correos/:id", middleware.RoleRequired("admin", "superuser", "analyst"), middleware.AreaCheck(), controllers.GetCorreoByID)
@@ -0,0 +1,604 @@
+package routes
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	configs "github.com/oficialrivas/sgi/config"
+	"github.com/oficialrivas/sgi/models"
+	"github.com/oficialrivas/sgi/utils"
+	"gorm.io/gorm"
+)
+
+// expectedRoute describe quién puede acceder a una ruta de SetupRouter.
+type expectedRoute struct {
+	method string
+	path   string
+	roles  []string // niveles admitidos por RoleRequired; nil si la ruta es pública
+	area   bool     // además protegida por AreaCheck
+}
+
+// expectedRoutes debe listar todas las rutas registradas en SetupRouter con la
+// protección que deberían tener, no la que tienen. Una ruta nueva que no aparezca
+// aquí hace fallar TestEveryRouteHasExpectation; las diferencias conocidas con el
+// cableado actual se listan en knownDefects.
+var expectedRoutes = []expectedRoute{
+	// Rutas públicas
+	{http.MethodPost, "/signup", nil, false},
+	{http.MethodPost, "/login", nil, false},
+	{http.MethodPost, "/webhook", nil, false},
+	{http.MethodPost, "/gestion", nil, false},
+	{http.MethodPost, "/gestion/por-area", nil, false},
+	{http.MethodPost, "/websms", nil, false},
+	{http.MethodPost, "/webtele", nil, false},
+	{http.MethodPost, "/gestion/area-modalidad", nil, false},
+	{http.MethodPost, "/gestion/user", nil, false},
+	{http.MethodPost, "/gestion/user-area-modalidad", nil, false},
+	{http.MethodPost, "/generate-token", nil, false},
+	{http.MethodPost, "/transparencia/verificar", nil, false},
+
+	// Usuarios
+	{http.MethodGet, "/users/:id", []string{"admin", "superuser"}, false},
+	{http.MethodGet, "/users", []string{"admin"}, false},
+	{http.MethodPut, "/users/:id", []string{"admin"}, false},
+	{http.MethodDelete, "/users/:id", []string{"admin"}, false},
+	{http.MethodGet, "/users/:id/otp-setup", []string{"admin"}, false},
+	{http.MethodPut, "/users/:id/password", []string{"admin"}, false},
+	{http.MethodGet, "/users/:id/messages", []string{"admin", "superuser", "analyst"}, false},
+	{http.MethodGet, "/users/nivel", []string{"admin", "superuser", "analyst"}, false},
+	{http.MethodGet, "/users-with-unprocessed-messages", []string{"admin", "superuser", "analyst"}, false},
+	{http.MethodGet, "/users-with-unprocessed-messages-by-redi/:redi", []string{"admin", "superuser", "analyst"}, false},
+	{http.MethodGet, "/users-with-unprocessed-messages-by-redi-and-nivel/:redi", []string{"admin", "superuser", "analyst"}, false},
+	{http.MethodGet, "/users-unprocessed-messages-user", []string{"admin", "superuser", "analyst"}, false},
+	{http.MethodPut, "/users/telefono/:telefono", []string{"user", "admin", "superuser", "analyst"}, false},
+	{http.MethodPost, "/upload_users", []string{"user", "admin", "superuser", "analyst"}, false},
+	{http.MethodPost, "/delete_users", []string{"user", "admin", "superuser", "analyst"}, false},
+	{http.MethodGet, "/users/cedula/:cedula", []string{"admin", "superuser", "analyst"}, false},
+	{http.MethodGet, "/users/alias/:alias", []string{"admin", "superuser", "analyst"}, false},
+	{http.MethodGet, "/users/telefono/:telefono", []string{"admin", "superuser", "analyst"}, false},
+
+	// Caso
+	{http.MethodPost, "/casos", []string{"admin", "superuser", "user"}, false},
+	{http.MethodGet, "/casos/:id", []string{"admin", "superuser", "analyst"}, true},
+	{http.MethodPut, "/casos/:id", []string{"admin", "superuser"}, true},
+	{http.MethodDelete, "/casos/:id", []string{"admin"}, true},
+	{http.MethodPut, "/casos/valorar/:id", []string{"admin", "superuser", "analyst"}, true},
+
+	// Documento
+	{http.MethodPost, "/documentos", []string{"admin", "superuser", "user"}, false},
+	{http.MethodGet, "/documentos/:id", []string{"admin", "superuser", "analyst"}, true},
+	{http.MethodPut, "/documentos/:id", []string{"admin", "superuser"}, true},
+	{http.MethodDelete, "/documentos/:id", []string{"admin"}, true},
+
+	// Pasaporte
+	{http.MethodPost, "/pasaportes", []string{"admin", "superuser", "user"}, false},
+	{http.MethodGet, "/pasaportes/:id", []string{"admin", "superuser", "analyst"}, true},
+	{http.MethodPut, "/pasaportes/:id", []string{"admin", "superuser"}, true},
+	{http.MethodDelete, "/pasaportes/:id", []string{"admin"}, true},
+
+	// Persona
+	{http.MethodPost, "/personas", []string{"admin", "superuser", "user"}, false},
+	{http.MethodGet, "/personas/:id", []string{"admin", "superuser", "analyst"}, true},
+	{http.MethodPut, "/personas/:id", []string{"admin", "superuser"}, true},
+	{http.MethodDelete, "/personas/:id", []string{"admin"}, true},
+	{http.MethodGet, "/personas/cedula/:cedula", []string{"admin", "superuser", "analyst"}, true},
+	{http.MethodGet, "/personas/pasaporte/:pasaporte", []string{"admin", "superuser", "analyst"}, true},
+	{http.MethodGet, "/personas/nombre/:nombre", []string{"admin", "superuser", "analyst"}, true},
+	{http.MethodGet, "/personas/nacionalidad/:nacionalidad", []string{"admin", "superuser", "analyst"}, true},
+	{http.MethodGet, "/personas", []string{"admin", "superuser", "analyst"}, true},
+	{http.MethodGet, "/personas/cedulas", []string{"admin", "superuser", "analyst"}, true},
+	{http.MethodGet, "/personas/search", []string{"admin", "superuser", "analyst"}, true},
+
+	// Vehiculo
+	{http.MethodPost, "/vehiculos", []string{"admin", "superuser", "user"}, false},
+	{http.MethodGet, "/vehiculos/:id", []string{"admin", "superuser", "analyst"}, true},
+	{http.MethodGet, "/vehiculos/search", []string{"admin", "superuser", "analyst"}, true},
+	{http.MethodPut, "/vehiculos/:id", []string{"admin", "superuser"}, true},
+	{http.MethodDelete, "/vehiculos/:id", []string{"admin"}, true},
+
+	// Empresa
+	{http.MethodPost, "/empresas", []string{"admin", "superuser", "user"}, false},
+	{http.MethodGet, "/empresas/:id", []string{"admin", "superuser", "analyst"}, true},
+	{http.MethodGet, "/empresas/search", []string{"admin", "superuser", "analyst"}, true},
+	{http.MethodPut, "/empresas/:id", []string{"admin", "superuser"}, true},
+	{http.MethodDelete, "/empresas/:id", []string{"admin"}, true},
+
+	// Dirección
+	{http.MethodPost, "/direcciones", []string{"admin", "superuser", "user"}, false},
+	{http.MethodGet, "/direcciones/:id", []string{"admin", "superuser", "analyst"}, true},
+	{http.MethodPut, "/direcciones/:id", []string{"admin", "superuser"}, true},
+	{http.MethodDelete, "/direcciones/:id", []string{"admin"}, true},
+
+	// Visa
+	{http.MethodPost, "/visas", []string{"admin", "superuser", "user"}, false},
+	{http.MethodGet, "/visas/:id", []string{"admin", "superuser", "analyst"}, true},
+	{http.MethodPut, "/visas/:id", []string{"admin", "superuser"}, true},
+	{http.MethodDelete, "/visas/:id", []string{"admin"}, true},
+
+	// IIO
+	{http.MethodPost, "/iios", []string{"admin", "superuser", "user"}, false},
+	{http.MethodGet, "/iios/:id", []string{"admin", "superuser", "analyst"}, true},
+	{http.MethodPut, "/iios/:id", []string{"admin", "superuser", "analyst"}, true},
+	{http.MethodDelete, "/iios/:id", []string{"admin"}, true},
+	{http.MethodGet, "/iios", []string{"admin", "superuser", "analyst"}, true},
+	{http.MethodGet, "/iios/filter", []string{"admin", "superuser", "analyst"}, false},
+	{http.MethodPost, "/gestion/iio", []string{"admin", "superuser", "analyst"}, false},
+	{http.MethodPost, "/gestion/iio/modalidad", []string{"admin", "superuser", "analyst"}, false},
+	{http.MethodPost, "/gestion/iio/modalidad/count", []string{"admin", "superuser", "analyst"}, false},
+
+	// Configuración
+	{http.MethodPost, "/configuracion/acceso-temporal", []string{"admin"}, false},
+	{http.MethodPost, "/configuracion/area", []string{"admin"}, false},
+	{http.MethodPut, "/configuracion/area", []string{"admin"}, false},
+	{http.MethodDelete, "/configuracion/area", []string{"admin"}, false},
+
+	// Transparencia
+	{http.MethodPost, "/transparencia/reporte", []string{"admin"}, false},
+
+	// Correo
+	{http.MethodPost, "/correos", []string{"admin", "superuser", "user"}, false},
+	{http.MethodGet, "/correos/:id", []string{"admin", "superuser", "analyst"}, true},
+	{http.MethodPut, "/correos/:id", []string{"admin", "superuser"}, true},
+	{http.MethodDelete, "/correos/:id", []string{"admin"}, true},
+
+	// Mensajes
+	{http.MethodGet, "/mensajes/:id", []string{"admin", "superuser", "analyst"}, true},
+	{http.MethodPut, "/mensajes/:id", []string{"admin"}, true},
+	{http.MethodDelete, "/mensajes/:id", []string{"admin"}, true},
+	{http.MethodGet, "/mensajes", []string{"admin", "superuser", "analyst"}, false},
+	{http.MethodGet, "/mensajes/filter", []string{"admin", "superuser", "analyst"}, false},
+	{http.MethodPut, "/mensajes/:id/procesado", []string{"admin", "superuser", "analyst"}, true},
+	{http.MethodPost, "/mensajes", []string{"admin", "superuser", "analyst", "user"}, false},
+	{http.MethodPost, "/create-and-send-mensaje", []string{"admin", "superuser", "analyst", "user"}, false},
+	{http.MethodPost, "/send-mensaje-to-user/:id", []string{"admin", "superuser", "analyst", "user"}, false},
+	{http.MethodPost, "/send_telegram/:id", []string{"admin", "superuser", "analyst", "user"}, false},
+	{http.MethodPost, "/send_messages_by_redi_tele/:redi", []string{"admin", "superuser", "analyst", "user"}, false},
+
+	// Redes
+	{http.MethodPost, "/redes", []string{"admin", "superuser", "user"}, false},
+	{http.MethodGet, "/redes/:id", []string{"admin", "superuser", "analyst"}, true},
+	{http.MethodPut, "/redes/:id", []string{"admin", "superuser"}, true},
+	{http.MethodDelete, "/redes/:id", []string{"admin"}, true},
+
+	// Ties
+	{http.MethodPost, "/ties", []string{"admin", "superuser", "user"}, false},
+	{http.MethodGet, "/ties/:id", []string{"admin", "superuser", "analyst"}, true},
+	{http.MethodPut, "/ties/:id", []string{"admin", "superuser", "analyst"}, true},
+	{http.MethodDelete, "/ties/:id", []string{"admin"}, true},
+	{http.MethodGet, "/ties", []string{"admin", "superuser", "analyst"}, false},
+
+	// Modalidades
+	{http.MethodPost, "/modalidades", []string{"admin", "superuser", "user"}, false},
+	{http.MethodGet, "/modalidades:id", []string{"admin", "superuser", "analyst"}, true},
+	{http.MethodPut, "/modalidades:id", []string{"admin", "superuser", "analyst"}, true},
+	{http.MethodDelete, "/modalidades:id", []string{"admin"}, true},
+	{http.MethodGet, "/modalidades", []string{"admin", "superuser", "analyst"}, false},
+}
+
+const (
+	defectAreaCheckWithoutID = "AreaCheck on a route without :id compares the user's area with an empty one, so only admin passes"
+	defectMissingAreaCheck   = "route has no AreaCheck, so users of any area reach entities of another area"
+	defectUnsupportedEntity  = "route has no AreaCheck and AreaCheck does not support this entity type"
+	defectModalidadPath      = "route is registered as /modalidades:id without the '/' and has no AreaCheck"
+)
+
+// knownDefects lista las rutas cuya protección de área actual no coincide con la
+// declarada en expectedRoutes. Las pruebas siguen comprobando autenticación y
+// roles, fijan el cableado de área actual y omiten sólo la expectativa de área
+// citando el defecto; al corregirlo hay que quitar la entrada.
+var knownDefects = map[string]string{
+	"GET /personas":                            defectAreaCheckWithoutID,
+	"GET /personas/search":                     defectAreaCheckWithoutID,
+	"GET /personas/cedulas":                    defectAreaCheckWithoutID,
+	"GET /personas/cedula/:cedula":             defectAreaCheckWithoutID,
+	"GET /personas/pasaporte/:pasaporte":       defectAreaCheckWithoutID,
+	"GET /personas/nombre/:nombre":             defectAreaCheckWithoutID,
+	"GET /personas/nacionalidad/:nacionalidad": defectAreaCheckWithoutID,
+	"GET /vehiculos/search":                    defectAreaCheckWithoutID,
+	"GET /empresas/search":                     defectAreaCheckWithoutID,
+	"GET /iios":                                defectAreaCheckWithoutID,
+
+	"PUT /casos/valorar/:id":      defectMissingAreaCheck,
+	"GET /iios/:id":               defectMissingAreaCheck,
+	"PUT /iios/:id":               defectMissingAreaCheck,
+	"GET /mensajes/:id":           defectMissingAreaCheck,
+	"PUT /mensajes/:id/procesado": defectMissingAreaCheck,
+
+	"GET /ties/:id":    defectUnsupportedEntity,
+	"PUT /ties/:id":    defectUnsupportedEntity,
+	"DELETE /ties/:id": defectUnsupportedEntity,
+
+	"GET /modalidades:id":    defectModalidadPath,
+	"PUT /modalidades:id":    defectModalidadPath,
+	"DELETE /modalidades:id": defectModalidadPath,
+}
+
+// knownDefect devuelve el defecto conocido de la ruta, si lo tiene
+func knownDefect(e expectedRoute) (string, bool) {
+	defect, ok := knownDefects[e.method+" "+e.path]
+	return defect, ok
+}
+
+// currentAreaCheck indica si una ruta con el defecto dado lleva hoy AreaCheck.
+// Sólo las rutas sin :id lo llevan; las demás no tienen ninguna comprobación de área.
+func currentAreaCheck(defect string) bool {
+	return defect == defectAreaCheckWithoutID
+}
+
+// skipIntendedArea deja constancia, como subprueba omitida, de la protección de
+// área declarada que la ruta todavía no cumple.
+func skipIntendedArea(t *testing.T, e expectedRoute, defect string) {
+	t.Helper()
+	t.Run("intended area protection", func(t *testing.T) {
+		t.Skipf("known defect in %s %s: %s", e.method, e.path, defect)
+	})
+}
+
+var routeParam = regexp.MustCompile(`:[A-Za-z_]+`)
+
+// buildPath sustituye los parámetros de la ruta; :id recibe el ID indicado y
+// el resto un valor cualquiera.
+func buildPath(path, id string) string {
+	return routeParam.ReplaceAllStringFunc(path, func(param string) string {
+		if param == ":id" {
+			return id
+		}
+		return "x"
+	})
+}
+
+// routeSegment devuelve el primer segmento de la ruta, que es el que AreaCheck
+// usa como tipo de entidad.
+func routeSegment(path string) string {
+	segment := strings.Split(path, "/")[1]
+	if i := strings.Index(segment, ":"); i >= 0 {
+		segment = segment[:i]
+	}
+	return segment
+}
+
+func hasRole(roles []string, rol string) bool {
+	for _, r := range roles {
+		if r == rol {
+			return true
+		}
+	}
+	return false
+}
+
+// expectAllowed indica si un usuario del nivel y área dados debe pasar los
+// middlewares de la ruta. Las entidades sembradas pertenecen a areaA.
+func expectAllowed(e expectedRoute, rol, area string) bool {
+	if !hasRole(e.roles, rol) {
+		return false
+	}
+	// Sin :id no hay una entidad concreta; el listado se filtra, no se niega
+	if !e.area || rol == "admin" || !strings.Contains(e.path, ":id") {
+		return true
+	}
+	return area == areaA
+}
+
+// expectAllowedNow indica si un usuario del nivel dado pasa hoy los middlewares
+// de una ruta con un defecto conocido. El área del usuario no cuenta: sin
+// AreaCheck nadie se compara, y AreaCheck sin :id compara con un área vacía, así
+// que sólo admin pasa.
+func expectAllowedNow(e expectedRoute, defect, rol string) bool {
+	if !hasRole(e.roles, rol) {
+		return false
+	}
+	return !currentAreaCheck(defect) || rol == "admin"
+}
+
+// withRollback ejecuta fn con configs.DB apuntando a una transacción que se
+// revierte al terminar, para que los handlers no alteren los datos sembrados.
+func withRollback(t *testing.T, fn func(tx *gorm.DB)) {
+	t.Helper()
+	base := configs.DB
+	tx := base.Begin()
+	configs.DB = tx
+	defer func() {
+		tx.Rollback()
+		configs.DB = base
+	}()
+	fn(tx)
+}
+
+func newRouter(probe gin.HandlerFunc) *gin.Engine {
+	r := gin.New()
+	r.Use(gin.RecoveryWithWriter(io.Discard), probe)
+	SetupRouter(r)
+	return r
+}
+
+func TestEveryRouteHasExpectation(t *testing.T) {
+	registered := map[string]bool{}
+	for _, route := range newRouter(func(c *gin.Context) {}).Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+
+	declared := map[string]bool{}
+	for _, e := range expectedRoutes {
+		key := e.method + " " + e.path
+		if declared[key] {
+			t.Errorf("duplicate expectation for %s", key)
+		}
+		declared[key] = true
+		if !registered[key] {
+			t.Errorf("expectation for %s but the route is not registered", key)
+		}
+	}
+
+	for key := range registered {
+		if !declared[key] {
+			t.Errorf("route %s has no authorization expectation in expectedRoutes", key)
+		}
+	}
+
+	for key := range knownDefects {
+		if !declared[key] {
+			t.Errorf("known defect listed for %s but the route is not in expectedRoutes", key)
+		}
+	}
+}
+
+// TestRouteMiddlewareChain comprueba sin base de datos que cada ruta lleva los
+// middlewares declarados en expectedRoutes.
+func TestRouteMiddlewareChain(t *testing.T) {
+	var chain []string
+	r := newRouter(func(c *gin.Context) {
+		chain = c.HandlerNames()
+		c.AbortWithStatus(http.StatusNoContent)
+	})
+
+	for _, e := range expectedRoutes {
+		e := e
+		t.Run(e.method+" "+e.path, func(t *testing.T) {
+			chain = nil
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(e.method, buildPath(e.path, uuid.NewString()), nil))
+
+			has := func(name string) bool {
+				for _, handler := range chain {
+					if strings.Contains(handler, "middleware."+name+".") {
+						return true
+					}
+				}
+				return false
+			}
+
+			protected := e.roles != nil
+			if has("AuthRequired") != protected {
+				t.Errorf("AuthRequired present = %v, want %v", has("AuthRequired"), protected)
+			}
+			if has("RoleRequired") != protected {
+				t.Errorf("RoleRequired present = %v, want %v", has("RoleRequired"), protected)
+			}
+			if defect, ok := knownDefect(e); ok {
+				if has("AreaCheck") != currentAreaCheck(defect) {
+					t.Errorf("AreaCheck present = %v, want %v as currently wired; if the defect was fixed, remove it from knownDefects", has("AreaCheck"), currentAreaCheck(defect))
+				}
+				skipIntendedArea(t, e, defect)
+				return
+			}
+			if has("AreaCheck") != e.area {
+				t.Errorf("AreaCheck present = %v, want %v", has("AreaCheck"), e.area)
+			}
+		})
+	}
+}
+
+// TestAuthorizationMatrix recorre todas las rutas con un usuario de cada nivel
+// en cada área contra una base de datos real. Cada solicitud corre dentro de una
+// transacción que se revierte, así que los handlers pueden ejecutarse sin alterar
+// los datos sembrados. Una solicitud se considera permitida si ningún middleware
+// la aborta; lo que responda después el handler no cambia el resultado.
+func TestAuthorizationMatrix(t *testing.T) {
+	requireDB(t)
+
+	var aborted bool
+	r := newRouter(func(c *gin.Context) {
+		c.Next()
+		aborted = c.IsAborted()
+	})
+
+	// Se envía el encabezado como lo hacen los clientes, con el prefijo "Bearer"
+	tokens := map[uuid.UUID]string{}
+	for _, area := range []string{areaA, areaB} {
+		for _, rol := range allRoles {
+			user := fixtures.users[area][rol]
+			token, _, err := utils.GenerateTokens(user.ID.String(), user.Nivel, user.Area)
+			if err != nil {
+				t.Fatalf("generate token for %s/%s: %v", area, rol, err)
+			}
+			tokens[user.ID] = "Bearer " + token
+		}
+	}
+
+	serve := func(t *testing.T, method, path, token string, grant *models.TemporaryAccess) (int, bool) {
+		t.Helper()
+		var w *httptest.ResponseRecorder
+		withRollback(t, func(tx *gorm.DB) {
+			if grant != nil {
+				if err := tx.Create(grant).Error; err != nil {
+					t.Fatalf("create temporary access: %v", err)
+				}
+			}
+
+			req := httptest.NewRequest(method, path, strings.NewReader("{}"))
+			req.Header.Set("Content-Type", "application/json")
+			if token != "" {
+				req.Header.Set("Authorization", token)
+			}
+
+			w = httptest.NewRecorder()
+			aborted = false
+			r.ServeHTTP(w, req)
+		})
+		return w.Code, aborted
+	}
+
+	for _, e := range expectedRoutes {
+		e := e
+		segment := routeSegment(e.path)
+		id, ok := fixtures.entities[segment]
+		if !ok {
+			id = uuid.New()
+		}
+		path := buildPath(e.path, id.String())
+
+		t.Run(e.method+" "+e.path, func(t *testing.T) {
+			defect, hasDefect := knownDefect(e)
+
+			status, denied := serve(t, e.method, path, "", nil)
+			if e.roles == nil {
+				if denied {
+					t.Errorf("anonymous: denied with %d on a public route", status)
+				}
+				return
+			}
+			if !denied || status != http.StatusUnauthorized {
+				t.Errorf("anonymous: got status %d (aborted=%v), want 401", status, denied)
+			}
+
+			for _, area := range []string{areaA, areaB} {
+				for _, rol := range allRoles {
+					user := fixtures.users[area][rol]
+					want := expectAllowed(e, rol, area)
+					if hasDefect {
+						want = expectAllowedNow(e, defect, rol)
+					}
+					status, denied := serve(t, e.method, path, tokens[user.ID], nil)
+					if want && denied {
+						t.Errorf("%s/%s: denied with %d, want allowed", rol, area, status)
+					}
+					if !want && (!denied || status != http.StatusForbidden) {
+						t.Errorf("%s/%s: got status %d (aborted=%v), want 403", rol, area, status, denied)
+					}
+				}
+			}
+
+			if hasDefect {
+				skipIntendedArea(t, e, defect)
+				return
+			}
+
+			// El acceso temporal abre la entidad a usuarios de otra área mientras no expire
+			if !e.area || !strings.Contains(e.path, ":id") {
+				return
+			}
+			for _, rol := range allRoles {
+				if rol == "admin" || !hasRole(e.roles, rol) {
+					continue
+				}
+				user := fixtures.users[areaB][rol]
+				grant := func(expiresAt time.Time) *models.TemporaryAccess {
+					return &models.TemporaryAccess{UserID: user.ID, EntityID: id, EntityType: segment, ExpiresAt: expiresAt}
+				}
+
+				status, denied := serve(t, e.method, path, tokens[user.ID], grant(time.Now().Add(time.Hour)))
+				if denied {
+					t.Errorf("%s/%s with temporary access: denied with %d, want allowed", rol, areaB, status)
+				}
+				status, denied = serve(t, e.method, path, tokens[user.ID], grant(time.Now().Add(-time.Hour)))
+				if !denied || status != http.StatusForbidden {
+					t.Errorf("%s/%s with expired access: got status %d (aborted=%v), want 403", rol, areaB, status, denied)
+				}
+			}
+		})
+	}
+}
+
+// TestHandlersAcceptBearerToken recorre las rutas cuyos handlers vuelven a leer
+// el encabezado Authorization con utils.ValidateJWT. Con el prefijo "Bearer",
+// como lo envían los clientes, el handler debe responder igual que con el token
+// solo y nunca con 401.
+func TestHandlersAcceptBearerToken(t *testing.T) {
+	requireDB(t)
+
+	admin := fixtures.users[areaA]["admin"]
+	token, _, err := utils.GenerateTokens(admin.ID.String(), admin.Nivel, admin.Area)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	form := func(values url.Values) (string, string) {
+		return "application/x-www-form-urlencoded", values.Encode()
+	}
+	jsonBody := func(body string) func() (string, string) {
+		return func() (string, string) { return "application/json", body }
+	}
+	iio := url.Values{
+		"descripcion": {"d"}, "fecha": {"2024-01-02"}, "lugar": {"l"}, "modalidad": {"m"}, "nombre": {"n"},
+		"parroquia": {"p"}, "redi": {"CAPITAL"}, "zodi": {"z"}, "tie": {"t"}, "area": {areaA},
+	}
+	mensaje := url.Values{"adi": {"a"}, "procesado": {"true"}}
+	for k, v := range iio {
+		mensaje[k] = v
+	}
+
+	// Cada cuerpo supera la validación de entrada para que el handler llegue a
+	// leer el token
+	routes := []struct {
+		method, path string
+		body         func() (string, string)
+	}{
+		{http.MethodPost, "/personas", jsonBody(`{"nombre":"Bearer","cedula":"V-30000001"}`)},
+		{http.MethodPost, "/vehiculos", jsonBody(`{}`)},
+		{http.MethodPost, "/empresas", jsonBody(`{}`)},
+		{http.MethodPost, "/direcciones", jsonBody(`{}`)},
+		{http.MethodPost, "/visas", jsonBody(`{}`)},
+		{http.MethodPost, "/correos", jsonBody(`{}`)},
+		{http.MethodPost, "/redes", jsonBody(`{}`)},
+		{http.MethodPost, "/modalidades", jsonBody(`{}`)},
+		{http.MethodPost, "/documentos", func() (string, string) {
+			return form(url.Values{"nombre": {"d"}, "user_id": {admin.ID.String()}})
+		}},
+		{http.MethodPost, "/pasaportes", func() (string, string) {
+			return form(url.Values{"codigo": {"p"}, "representante_id": {fixtures.entities["personas"].String()}, "user_id": {admin.ID.String()}})
+		}},
+		{http.MethodPost, "/iios", func() (string, string) { return form(iio) }},
+		{http.MethodPost, "/mensajes", func() (string, string) { return form(mensaje) }},
+		{http.MethodPost, "/create-and-send-mensaje", jsonBody(`{"descripcion":"d","redi":"CAPITAL","zodi":"z","adi":"a","procesado":true}`)},
+		{http.MethodPost, "/send-mensaje-to-user/:id", jsonBody(`{"descripcion":"d","redi":"CAPITAL"}`)},
+		{http.MethodPost, "/send_telegram/:id", jsonBody(`{"mensaje":"m"}`)},
+		{http.MethodPost, "/send_messages_by_redi_tele/:redi", jsonBody(`{"mensaje":"m"}`)},
+	}
+
+	r := newRouter(func(c *gin.Context) {})
+	serve := func(method, path, authorization string, body func() (string, string)) *httptest.ResponseRecorder {
+		var w *httptest.ResponseRecorder
+		withRollback(t, func(tx *gorm.DB) {
+			contentType, payload := body()
+			req := httptest.NewRequest(method, path, strings.NewReader(payload))
+			req.Header.Set("Content-Type", contentType)
+			req.Header.Set("Authorization", authorization)
+			w = httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+		})
+		return w
+	}
+
+	for _, route := range routes {
+		route := route
+		t.Run(route.method+" "+route.path, func(t *testing.T) {
+			path := buildPath(route.path, fixtures.entities[routeSegment(route.path)].String())
+
+			raw := serve(route.method, path, token, route.body)
+			bearer := serve(route.method, path, "Bearer "+token, route.body)
+			if bearer.Code == http.StatusUnauthorized {
+				t.Fatalf("Bearer token rejected by the handler: %s", bearer.Body.String())
+			}
+			if bearer.Code != raw.Code {
+				t.Errorf("status with Bearer prefix = %d, without = %d", bearer.Code, raw.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package routes
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/oficialrivas/sgi/models"
+	"github.com/oficialrivas/sgi/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TestTransparencyReportCountsFromAuditLog comprueba que cada cifra del reporte
+// se cuenta desde el audit log, de modo que borrar datos no cambia el reporte de
+// un período ya cerrado, que el día final se incluye completo y que la firma del
+// reporte se verifica y rechaza cualquier cambio.
+func TestTransparencyReportCountsFromAuditLog(t *testing.T) {
+	requireDB(t)
+	t.Setenv("REPORT_SIGNING_KEY", base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32))))
+
+	admin := fixtures.users[areaA]["admin"]
+	token, _, err := utils.GenerateTokens(admin.ID.String(), admin.Nivel, admin.Area)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newRouter(func(c *gin.Context) {})
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// report pide el reporte del período y devuelve también la respuesta firmada tal cual
+	report := func(t *testing.T, desde, hasta string) (models.TransparencyReport, []byte) {
+		t.Helper()
+		w := do(http.MethodPost, "/transparencia/reporte", fmt.Sprintf(`{"start_date":%q,"end_date":%q}`, desde, hasta))
+		if w.Code != http.StatusOK {
+			t.Fatalf("POST /transparencia/reporte returned %d: %s", w.Code, w.Body.String())
+		}
+		var signed models.SignedTransparencyReport
+		if err := json.Unmarshal(w.Body.Bytes(), &signed); err != nil {
+			t.Fatal(err)
+		}
+		var report models.TransparencyReport
+		if err := json.Unmarshal(signed.Reporte, &report); err != nil {
+			t.Fatal(err)
+		}
+		return report, w.Body.Bytes()
+	}
+	today := time.Now().UTC().Format("2006-01-02")
+
+	withRollback(t, func(tx *gorm.DB) {
+		segunda := models.Persona{Nombre: "Segunda", Cedula: "V-40000001", Area: areaA}
+		if err := tx.Omit(clause.Associations).Create(&segunda).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		grant := fmt.Sprintf(`{"user_id":%q,"entity_id":%q,"entity_type":"personas","expires_at":%q}`,
+			fixtures.users[areaB]["analyst"].ID, segunda.ID, time.Now().Add(time.Hour).Format(time.RFC3339))
+		if w := do(http.MethodPost, "/configuracion/acceso-temporal", grant); w.Code != http.StatusOK {
+			t.Fatalf("POST /configuracion/acceso-temporal returned %d: %s", w.Code, w.Body.String())
+		}
+
+		var before int64
+		tx.Model(&models.AuditLog{}).Count(&before)
+		if w := do(http.MethodGet, "/personas", ""); w.Code != http.StatusOK {
+			t.Fatalf("GET /personas returned %d: %s", w.Code, w.Body.String())
+		}
+		var after int64
+		tx.Model(&models.AuditLog{}).Count(&after)
+		if after-before != 1 {
+			t.Errorf("GET /personas wrote %d audit entries, want a single entry for the whole list", after-before)
+		}
+
+		w := do(http.MethodPost, "/personas", `{"nombre":"Creada","cedula":"V-40000002"}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("POST /personas returned %d: %s", w.Code, w.Body.String())
+		}
+		var creada models.Persona
+		if err := json.Unmarshal(w.Body.Bytes(), &creada); err != nil {
+			t.Fatal(err)
+		}
+		if w := do(http.MethodPut, "/personas/"+creada.ID.String(), `{"nombre":"Corregida","cedula":"V-40000002"}`); w.Code != http.StatusOK {
+			t.Fatalf("PUT /personas/:id returned %d: %s", w.Code, w.Body.String())
+		}
+		if w := do(http.MethodDelete, "/personas/"+segunda.ID.String(), ""); w.Code != http.StatusNoContent {
+			t.Fatalf("DELETE /personas/:id returned %d: %s", w.Code, w.Body.String())
+		}
+
+		got, signed := report(t, today, today)
+		counts := []struct {
+			name      string
+			got, want int64
+		}{
+			{"personas_creadas", got.PersonasCreadas, 1},
+			{"personas_consultadas", got.PersonasConsultadas, 2},
+			{"personas_corregidas", got.PersonasCorregidas, 1},
+			{"personas_eliminadas", got.PersonasEliminadas, 1},
+			{"accesos_temporales", got.AccesosTemporales, 1},
+		}
+		for _, count := range counts {
+			if count.got != count.want {
+				t.Errorf("%s = %d, want %d", count.name, count.got, count.want)
+			}
+		}
+		if got.SolicitudesAtendidas != nil || got.NoRegistrado["solicitudes_atendidas"] == "" {
+			t.Errorf("solicitudes_atendidas must be null and explained in no_registrado, got %v / %v", got.SolicitudesAtendidas, got.NoRegistrado)
+		}
+
+		// La respuesta firmada se verifica tal cual y se rechaza si cambia el reporte
+		if w := do(http.MethodPost, "/transparencia/verificar", string(signed)); w.Code != http.StatusOK {
+			t.Errorf("POST /transparencia/verificar with the signed report returned %d: %s", w.Code, w.Body.String())
+		}
+		var tampered models.SignedTransparencyReport
+		if err := json.Unmarshal(signed, &tampered); err != nil {
+			t.Fatal(err)
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal(tampered.Reporte, &fields); err != nil {
+			t.Fatal(err)
+		}
+		fields["personas_eliminadas"] = 0
+		if tampered.Reporte, err = json.Marshal(fields); err != nil {
+			t.Fatal(err)
+		}
+		body, err := json.Marshal(tampered)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if w := do(http.MethodPost, "/transparencia/verificar", string(body)); w.Code != http.StatusBadRequest {
+			t.Errorf("POST /transparencia/verificar with an edited report returned %d, want 400", w.Code)
+		}
+
+		// Borrar la fila del acceso no cambia lo que ya se otorgó en el período
+		if err := tx.Where("user_id = ?", fixtures.users[areaB]["analyst"].ID).Delete(&models.TemporaryAccess{}).Error; err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := report(t, today, today); got.AccesosTemporales != 1 {
+			t.Errorf("accesos_temporales after deleting the grant row = %d, want 1", got.AccesosTemporales)
+		}
+
+		// Los dos días del período se incluyen completos y nada fuera de ellos
+		for _, at := range []time.Time{
+			time.Date(2019, 12, 31, 23, 59, 59, 0, time.UTC),
+			time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2020, 1, 31, 23, 59, 59, 0, time.UTC),
+			time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC),
+		} {
+			entry := models.AuditLog{Accion: models.AccionCrear, EntityType: "personas", EntityID: uuid.New()}
+			if err := tx.Create(&entry).Error; err != nil {
+				t.Fatal(err)
+			}
+			if err := tx.Model(&entry).UpdateColumn("created_at", at).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got, _ := report(t, "2020-01-01", "2020-01-31"); got.PersonasCreadas != 2 {
+			t.Errorf("personas_creadas for 2020-01-01..2020-01-31 = %d, want 2 (both boundary days, nothing outside)", got.PersonasCreadas)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"os"
+)
+
+// reportSigningKey obtiene la clave Ed25519 a partir de REPORT_SIGNING_KEY,
+// una semilla de 32 bytes codificada en base64
+func reportSigningKey() (ed25519.PrivateKey, error) {
+	encoded := os.Getenv("REPORT_SIGNING_KEY")
+	if encoded == "" {
+		return nil, errors.New("REPORT_SIGNING_KEY is not configured")
+	}
+
+	seed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, errors.New("REPORT_SIGNING_KEY must be a base64 encoded 32 byte seed")
+	}
+
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// SignReport firma los datos y devuelve la firma y la clave pública en base64
+func SignReport(data []byte) (string, string, error) {
+	key, err := reportSigningKey()
+	if err != nil {
+		return "", "", err
+	}
+
+	signature := ed25519.Sign(key, data)
+	publicKey := key.Public().(ed25519.PublicKey)
+	return base64.StdEncoding.EncodeToString(signature), base64.StdEncoding.EncodeToString(publicKey), nil
+}
+
+// VerifyReport comprueba que la firma corresponda a los datos y a la clave del servidor
+func VerifyReport(data []byte, signature string) error {
+	key, err := reportSigningKey()
+	if err != nil {
+		return err
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return errors.New("invalid signature encoding")
+	}
+
+	if !ed25519.Verify(key.Public().(ed25519.PublicKey), data, sig) {
+		return errors.New("invalid report signature")
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestSignAndVerifyReport(t *testing.T) {
+	t.Setenv("REPORT_SIGNING_KEY", base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32))))
+
+	data := []byte(`{"personas_creadas":3}`)
+	signature, publicKey, err := SignReport(data)
+	if err != nil {
+		t.Fatalf("SignReport: %v", err)
+	}
+	if publicKey == "" {
+		t.Fatal("SignReport returned an empty public key")
+	}
+
+	if err := VerifyReport(data, signature); err != nil {
+		t.Errorf("VerifyReport on the signed data: %v", err)
+	}
+	if err := VerifyReport([]byte(`{"personas_creadas":4}`), signature); err == nil {
+		t.Error("VerifyReport accepted modified data")
+	}
+	if err := VerifyReport(data, "%%%"); err == nil {
+		t.Error("VerifyReport accepted a malformed signature")
+	}
+}
+
+func TestSignReportWithoutKey(t *testing.T) {
+	t.Setenv("REPORT_SIGNING_KEY", "")
+
+	if _, _, err := SignReport([]byte("{}")); err == nil {
+		t.Error("SignReport succeeded without REPORT_SIGNING_KEY")
+	}
+
+	t.Setenv("REPORT_SIGNING_KEY", base64.StdEncoding.EncodeToString([]byte("short")))
+	if _, _, err := SignReport([]byte("{}")); err == nil {
+		t.Error("SignReport accepted a seed that is not 32 bytes")
+	}
+}
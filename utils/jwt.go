@@ -3,6 +3,7 @@ package utils
 import (
 	"errors"
 	"os"
+	"strings"
 	"time"
 
 	"log"
@@ -15,9 +16,9 @@ var jwtKey []byte
 var refreshKey []byte
 
 func init() {
-	// Cargar variables de entorno desde el archivo .env
+	// Cargar variables de entorno desde el archivo .env; si no existe se usan las del proceso
 	if err := godotenv.Load(); err != nil {
-		log.Fatalf("Error loading .env file: %v", err)
+		log.Printf("No .env file found, using process environment: %v", err)
 	}
 	jwtKey = []byte(os.Getenv("JWT_SECRET"))
 	refreshKey = []byte(os.Getenv("REFRESH_SECRET"))
@@ -64,7 +65,10 @@ func generateJWT(userID, role, area string, duration time.Duration, key []byte)
 	return tokenString, nil
 }
 
+// ValidateJWT valida el token y devuelve sus claims. Acepta el valor del
+// encabezado Authorization tal cual, con o sin el prefijo "Bearer".
 func ValidateJWT(tokenString string, isRefreshToken bool) (*Claims, error) {
+	tokenString = strings.TrimSpace(strings.TrimPrefix(tokenString, "Bearer "))
 	claims := &Claims{}
 	key := jwtKey
 	if isRefreshToken {
@@ -0,0 +1,28 @@
+package utils
+
+import "testing"
+
+func TestValidateJWTAcceptsBearerPrefix(t *testing.T) {
+	access, refresh, err := GenerateTokens("user-1", "admin", "SEP")
+	if err != nil {
+		t.Fatalf("GenerateTokens: %v", err)
+	}
+
+	for _, header := range []string{access, "Bearer " + access} {
+		claims, err := ValidateJWT(header, false)
+		if err != nil {
+			t.Errorf("ValidateJWT(%.12q...): %v", header, err)
+			continue
+		}
+		if claims.UserID != "user-1" || claims.Area != "SEP" {
+			t.Errorf("ValidateJWT returned claims %+v", claims)
+		}
+	}
+
+	if _, err := ValidateJWT("Bearer "+refresh, true); err != nil {
+		t.Errorf("ValidateJWT on a refresh token: %v", err)
+	}
+	if _, err := ValidateJWT("Bearer not-a-token", false); err == nil {
+		t.Error("ValidateJWT accepted a malformed token")
+	}
+}